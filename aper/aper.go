@@ -25,64 +25,3 @@ type OctetString []byte
 
 type Integer int64
 type Enumerated int64
-
-type Constraint struct {
-	Lb int64
-	Ub int64
-}
-
-func (c *Constraint) Range() uint64 {
-	if c.Lb > c.Ub {
-		return 0
-	}
-	return uint64(c.Ub - c.Lb + 1)
-}
-
-func (aw *AperWriter) writeExtBit(bitsLength uint64, e bool, c *Constraint) (int64, uint64, error) {
-	exBit := false
-	var lRange uint64 = 0    //length range
-	var lowerBound int64 = 0 //length lower bound, default=0
-
-	if c != nil {
-		if lowerBound = c.Lb; lowerBound < 0 { //make sure lower bound is not negative
-			return 0, 0, ErrConstraint
-		}
-		if int64(bitsLength) <= c.Ub {
-			lRange = (c.Range())
-		} else if !e {
-			//err = ErrInextensible
-			return 0, 0, ErrInextensible
-		} else {
-			exBit = true
-		}
-	}
-
-	if e {
-		if err := aw.WriteBool(exBit); err != nil {
-			return 0, 0, nil
-		}
-	}
-	return lowerBound, lRange, nil
-}
-
-func (ar *AperReader) readExBit(c *Constraint, e bool) (lRange uint64, lowerBound int64, err error) {
-	var exBit bool = false
-	if e { //read extension bit
-		if exBit, err = ar.ReadBool(); err != nil {
-			return 0, 0, err
-		}
-	}
-
-	if c != nil {
-		if lowerBound = c.Lb; lowerBound < 0 { //make sure lower bound is not negative
-			return 0, 0, ErrConstraint
-		}
-		if !exBit {
-			lRange = c.Range()
-		}
-		if uint64(c.Ub) > POW_16 {
-			lRange = c.Range()
-		}
-	}
-	return lRange, lowerBound, nil
-}
\ No newline at end of file
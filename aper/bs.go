@@ -142,9 +142,11 @@ func (bs *bitstreamWriter) WriteBits(content []byte, nbits uint) (err error) {
 
 /********** BITSTREAM READER ***************/
 type bitstreamReader struct {
-	r     io.Reader
-	b     [1]byte //read buffer
-	index uint8   //number of read bits / index of the next bit to read [0:8]
+	r         io.Reader
+	b         [1]byte //read buffer
+	index     uint8   //number of read bits / index of the next bit to read [0:8]
+	limited   bool    //whether the reader is limited to a number of bits
+	remaining uint64  //number of bits left to read when limited
 }
 
 func NewBitStreamReader(r io.Reader) *bitstreamReader {
@@ -154,7 +156,21 @@ func NewBitStreamReader(r io.Reader) *bitstreamReader {
 	}
 }
 
+// consume accounts for nbits read from a limited reader.
+func (bs *bitstreamReader) consume(nbits uint) error {
+	if bs.limited {
+		if uint64(nbits) > bs.remaining {
+			return ErrIncomplete
+		}
+		bs.remaining -= uint64(nbits)
+	}
+	return nil
+}
+
 func (bs *bitstreamReader) ReadBool() (bool, error) {
+	if err := bs.consume(1); err != nil {
+		return Zero, err
+	}
 	if bs.index == 8 { //read next byte to the buffer
 		if _, err := bs.r.Read(bs.b[:]); err != nil && err != io.EOF {
 			return Zero, err
@@ -175,6 +191,9 @@ func (bs *bitstreamReader) ReadBits(nbits uint) (output []byte, err error) {
 	if nbits == 0 { //read nothing
 		return
 	}
+	if err = bs.consume(nbits); err != nil {
+		return
+	}
 
 	nOutputBytes := (nbits + 7) >> 3    //number of output bytes
 	output = make([]byte, nOutputBytes) //prepare output
@@ -219,6 +238,13 @@ func (bs *bitstreamReader) ReadBits(nbits uint) (output []byte, err error) {
 }
 
 func (bs *bitstreamReader) align() {
+	if bs.index < 8 {
+		if skip := uint64(8 - bs.index); skip < bs.remaining {
+			bs.remaining -= skip
+		} else {
+			bs.remaining = 0
+		}
+	}
 	bs.index = 8
 }
 
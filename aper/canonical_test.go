@@ -0,0 +1,168 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// canonicalReader returns a canonical reader of the hex encoding s.
+func canonicalReader(t *testing.T, s string) *AperReader {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ar := NewReader(bytes.NewReader(b))
+	ar.SetCanonical(true)
+	return ar
+}
+
+// encodeCanonicalTest returns the encoding written by encode to a canonical
+// writer.
+func encodeCanonicalTest(t *testing.T, encode func(aw *AperWriter) error) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	aw.SetCanonical(true)
+	if err := encode(aw); err != nil {
+		t.Fatalf("encode error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAperReader_CanonicalInteger(t *testing.T) {
+	tests := []struct {
+		name      string
+		pc        *PerConstraint
+		input     string
+		value     int64
+		canonical bool
+	}{
+		{"unconstrained", nil, "0105", 5, true},
+		{"unconstrained, leading 00", nil, "020005", 5, false},
+		{"unconstrained, sign octet", nil, "0200ff", 255, true},
+		{"unconstrained, leading ff", nil, "02ff80", -128, false},
+		{"semi-constrained", AtLeast(-1), "0106", 5, true},
+		{"semi-constrained, leading 00", AtLeast(-1), "020006", 5, false},
+		{"0..2^32-1", Bounded(0, 1<<32-1), "0005", 5, true},
+		{"0..2^32-1, leading 00", Bounded(0, 1<<32-1), "400005", 5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.input)
+			v, err := NewReader(bytes.NewReader(b)).ReadIntegerWith(tt.pc)
+			if err != nil || v != tt.value {
+				t.Fatalf("ReadIntegerWith() = %d, %v, want %d", v, err, tt.value)
+			}
+			v, err = canonicalReader(t, tt.input).ReadIntegerWith(tt.pc)
+			if tt.canonical && (err != nil || v != tt.value) {
+				t.Errorf("canonical ReadIntegerWith() = %d, %v, want %d", v, err, tt.value)
+			}
+			if !tt.canonical && !errors.Is(err, ErrNonCanonical) {
+				t.Errorf("canonical ReadIntegerWith() error = %v, want %v", err, ErrNonCanonical)
+			}
+		})
+	}
+}
+
+// the SEQUENCE of TestSequenceEncoder_Default
+func TestSequenceEncoder_Canonical(t *testing.T) {
+	c := &Constraint{Lb: 0, Ub: 7}
+	writeInt := func(aw *AperWriter, v int64) error { return aw.WriteInteger(v, c, false) }
+	readInt := func(ar *AperReader) (int64, error) { return ar.ReadInteger(c, false) }
+	se := SequenceEncoder{
+		Extensible: true,
+		Root: []SequenceField{
+			DefaultField(int64(3), 3, writeInt),
+			{Encode: func(aw *AperWriter) error { return aw.WriteBool(true) }},
+		},
+		Extensions: [][]SequenceField{{DefaultField(int64(1), 1, writeInt)}},
+		Defaults:   DefaultBasic,
+	}
+	if got := hex.EncodeToString(encodeCanonicalTest(t, se.Encode)); got != "20" {
+		t.Errorf("Encode() = %s, want 20", got)
+	}
+	if got := hex.EncodeToString(encodeCanonicalTest(t, func(aw *AperWriter) error {
+		return aw.WriteOpenTypeValue(&se)
+	})); got != "0120" {
+		t.Errorf("WriteOpenTypeValue() = %s, want 0120", got)
+	}
+
+	var a, cv int64
+	var b bool
+	sd := SequenceDecoder{
+		Extensible: true,
+		Root: []SequenceFieldDecoder{
+			DefaultFieldDecoder(&a, 3, readInt),
+			{Decode: func(ar *AperReader) (err error) {
+				b, err = ar.ReadBool()
+				return
+			}},
+		},
+		Extensions: [][]SequenceFieldDecoder{{DefaultFieldDecoder(&cv, 1, readInt)}},
+	}
+	if err := sd.Decode(canonicalReader(t, "20")); err != nil || a != 3 || !b || cv != 1 {
+		t.Errorf("Decode() = %d, %v, %d, %v, want 3, true, 1", a, b, cv, err)
+	}
+	if err := sd.Decode(canonicalReader(t, "dc040190")); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrNonCanonical)
+	}
+	if err := canonicalReader(t, "04dc040190").ReadOpenTypeValue(&sd); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("ReadOpenTypeValue() error = %v, want %v", err, ErrNonCanonical)
+	}
+}
+
+func TestSetOf_Canonical(t *testing.T) {
+	c := &Constraint{Lb: 0, Ub: 8}
+	items := []*ConstrainedOctetString{{C: c, Value: []byte("b")}, {C: c, Value: []byte("a")}}
+	decode := func(ar *AperReader) (*ConstrainedOctetString, error) {
+		v := &ConstrainedOctetString{C: c}
+		return v, v.Decode(ar)
+	}
+	sorted := encodeCanonicalTest(t, func(aw *AperWriter) error { return WriteSetOf(items, aw, nil, false) })
+	unsorted := encodeTest(t, func(aw *AperWriter) error { return WriteSetOf(items, aw, nil, false) })
+	if bytes.Equal(sorted, unsorted) {
+		t.Fatalf("canonical WriteSetOf() = %x, want it sorted", sorted)
+	}
+	got, err := ReadSetOf(decode, canonicalReader(t, hex.EncodeToString(sorted)), nil, false)
+	if err != nil || len(got) != 2 || string(got[0].Value) != "a" || string(got[1].Value) != "b" {
+		t.Errorf("ReadSetOf() = %v, %v, want [a b]", got, err)
+	}
+	if _, err = ReadSetOf(decode, canonicalReader(t, hex.EncodeToString(unsorted)), nil, false); !errors.Is(err, ErrNonCanonical) {
+		t.Errorf("ReadSetOf() error = %v, want %v", err, ErrNonCanonical)
+	}
+	if _, err = ReadSetOf(decode, NewReader(bytes.NewReader(unsorted)), nil, false); err != nil {
+		t.Errorf("ReadSetOf() error = %v", err)
+	}
+}
+
+func TestAperReader_ReadNamedBitString(t *testing.T) {
+	tests := []struct {
+		name      string
+		pc        *PerConstraint
+		bits      BitString
+		canonical bool
+	}{
+		{"trimmed", nil, BitString{Bytes: []byte{0xa0}, NumBits: 3}, true},
+		{"trailing 0", nil, BitString{Bytes: []byte{0xa0}, NumBits: 8}, false},
+		{"padded to lb", Bounded(4, 8), BitString{Bytes: []byte{0x80}, NumBits: 4}, true},
+		{"past lb", Bounded(4, 8), BitString{Bytes: []byte{0x80}, NumBits: 5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := hex.EncodeToString(encodeTest(t, func(aw *AperWriter) error { return tt.bits.Encode(aw, tt.pc) }))
+			b, err := canonicalReader(t, input).ReadNamedBitString(tt.pc)
+			if tt.canonical && (err != nil || b.String() != tt.bits.String()) {
+				t.Errorf("ReadNamedBitString() = %s, %v, want %s", b, err, tt.bits)
+			}
+			if !tt.canonical && !errors.Is(err, ErrNonCanonical) {
+				t.Errorf("ReadNamedBitString() error = %v, want %v", err, ErrNonCanonical)
+			}
+		})
+	}
+}
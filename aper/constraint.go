@@ -0,0 +1,53 @@
+package aper
+
+import "github.com/lvdund/asn1go/internal/per"
+
+// ConstraintCategory is the X.691 category selected by a PER-visible
+// constraint: it decides how a whole number or a length is encoded.
+type ConstraintCategory = per.ConstraintCategory
+
+const (
+	Unconstrained   = per.Unconstrained   // no lower bound: (MIN..MAX) or (MIN..ub)
+	SemiConstrained = per.SemiConstrained // lower bound only: (lb..MAX)
+	Constrained     = per.Constrained     // both bounds: (lb..ub)
+)
+
+// Constraint is a fixed range (lb..ub).
+type Constraint = per.Constraint
+
+// PerConstraint is a PER-visible constraint on an INTEGER value or on the
+// size of a string or a list. Unlike Constraint, either bound may be left
+// open (MIN/MAX) and the extension marker is part of the constraint itself.
+//
+//	INTEGER (0..65535, ...)      Bounded(0, 65535).Extend()
+//	INTEGER (0..MAX)             AtLeast(0)
+//	INTEGER (MIN..10)            AtMost(10)
+//	SIZE (1..MAX)                AtLeast(1)
+//	INTEGER (0..10, ..., 20..30) Bounded(0, 10).Extend(Constraint{Lb: 20, Ub: 30})
+type PerConstraint = per.PerConstraint
+
+// Bounded returns the fully constrained range (lb..ub).
+func Bounded(lb, ub int64) *PerConstraint {
+	return per.Bounded(lb, ub)
+}
+
+// AtLeast returns the semi-constrained range (lb..MAX).
+func AtLeast(lb int64) *PerConstraint {
+	return per.AtLeast(lb)
+}
+
+// AtMost returns the range (MIN..ub), which PER encodes as unconstrained.
+func AtMost(ub int64) *PerConstraint {
+	return per.AtMost(ub)
+}
+
+// Unbounded returns the range (MIN..MAX).
+func Unbounded() *PerConstraint {
+	return per.Unbounded()
+}
+
+// NewPerConstraint converts the legacy (Constraint, extensible) pair used by
+// the older Write*/Read* signatures. A nil c means no constraint.
+func NewPerConstraint(c *Constraint, e bool) *PerConstraint {
+	return per.NewPerConstraint(c, e)
+}
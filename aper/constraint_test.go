@@ -0,0 +1,233 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+func TestPerConstraint_Category(t *testing.T) {
+	tests := []struct {
+		name string
+		pc   *PerConstraint
+		want ConstraintCategory
+	}{
+		{"nil", nil, Unconstrained},
+		{"MIN..MAX", Unbounded(), Unconstrained},
+		{"MIN..10", AtMost(10), Unconstrained},
+		{"0..MAX", AtLeast(0), SemiConstrained},
+		{"0..10", Bounded(0, 10), Constrained},
+		{"legacy nil", NewPerConstraint(nil, true), Unconstrained},
+		{"legacy 1..8", NewPerConstraint(&Constraint{Lb: 1, Ub: 8}, false), Constrained},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pc.Category(); got != tt.want {
+				t.Errorf("Category() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	ext := Bounded(0, 10).Extend(Constraint{Lb: 20, Ub: 30})
+	if !ext.IsExtensible() || len(ext.Additions) != 1 {
+		t.Errorf("Extend() = %+v", ext)
+	}
+	if full := Bounded(math.MinInt64, math.MaxInt64); full.Range() != 0 || full.Span() != math.MaxUint64 {
+		t.Errorf("full int64 range: Range() = %d, Span() = %d", full.Range(), full.Span())
+	}
+}
+
+func TestAperWriter_WriteIntegerWith(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int64
+		pc       *PerConstraint
+		expected string
+	}{
+		{"0..MAX value 0", 0, AtLeast(0), "0100"},
+		{"0..MAX value 5", 5, AtLeast(0), "0105"},
+		{"0..MAX value 256", 256, AtLeast(0), "020100"},
+		{"-5..MAX value -5", -5, AtLeast(-5), "0100"},
+		{"MIN..10 value -1", -1, AtMost(10), "01ff"},
+		{"MIN..10 value -129", -129, AtMost(10), "02ff7f"},
+		{"MIN..MAX value 128", 128, Unbounded(), "020080"},
+		{"0..2^32-1 value 1", 1, Bounded(0, math.MaxUint32), "0001"},
+		{"0..2^32-1 value 256", 256, Bounded(0, math.MaxUint32), "400100"},
+		{"0..2^32-1 value max", math.MaxUint32, Bounded(0, math.MaxUint32), "c0ffffffff"},
+		{"0..65535 extensible", 300, Bounded(0, 65535).Extend(), "00012c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			aw := NewWriter(&buf)
+			if err := aw.WriteIntegerWith(tt.value, tt.pc); err != nil {
+				t.Fatalf("WriteIntegerWith() error = %v", err)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteIntegerWith() = %s, want %s", got, tt.expected)
+			}
+
+			ar := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ar.ReadIntegerWith(tt.pc)
+			if err != nil {
+				t.Fatalf("ReadIntegerWith() error = %v", err)
+			}
+			if value != tt.value {
+				t.Errorf("ReadIntegerWith() = %d, want %d", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestAperWriter_WriteIntegerWith_OutOfRoot(t *testing.T) {
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := aw.WriteIntegerWith(11, Bounded(0, 10)); err == nil {
+		t.Error("expected an error for a value outside a non-extensible constraint")
+	}
+	if err := aw.WriteIntegerWith(-1, AtLeast(0)); err == nil {
+		t.Error("expected an error for a value below a semi-constrained lower bound")
+	}
+	if err := aw.WriteIntegerWith(0, Bounded(10, 0)); err == nil {
+		t.Error("expected an error for an inverted constraint")
+	}
+}
+
+func TestAperWriter_WriteOctetStringWith(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    []byte
+		pc       *PerConstraint
+		expected string
+	}{
+		{"SIZE(1..MAX)", []byte("abc"), AtLeast(1), "03616263"},
+		{"SIZE(MIN..4)", []byte("ab"), AtMost(4), "406162"},
+		{"SIZE(3) fixed", []byte("abc"), Bounded(3, 3), "616263"},
+		{"SIZE(1..4, ...) in root", []byte("ab"), Bounded(1, 4).Extend(), "206162"},
+		{"SIZE(1..4, ...) extension", []byte("abcde"), Bounded(1, 4).Extend(), "80056162636465"},
+		{"SIZE(0..70000)", []byte("ab"), Bounded(0, 70000), "026162"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			aw := NewWriter(&buf)
+			if err := aw.WriteOctetStringWith(tt.value, tt.pc); err != nil {
+				t.Fatalf("WriteOctetStringWith() error = %v", err)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteOctetStringWith() = %s, want %s", got, tt.expected)
+			}
+
+			ar := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ar.ReadOctetStringWith(tt.pc)
+			if err != nil {
+				t.Fatalf("ReadOctetStringWith() error = %v", err)
+			}
+			if !bytes.Equal(value, tt.value) {
+				t.Errorf("ReadOctetStringWith() = %x, want %x", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestWriteSequenceOfWith(t *testing.T) {
+	items := []*testInteger{{1}, {2}, {3}}
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := WriteSequenceOfWith(items, aw, Bounded(1, 8)); err != nil {
+		t.Fatalf("WriteSequenceOfWith() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	ar := NewReader(bytes.NewReader(buf.Bytes()))
+	got, err := ReadSequenceOfExWith(func() *testInteger { return new(testInteger) }, ar, Bounded(1, 8))
+	if err != nil {
+		t.Fatalf("ReadSequenceOfExWith() error = %v", err)
+	}
+	if len(got) != len(items) {
+		t.Fatalf("ReadSequenceOfExWith() returned %d items, want %d", len(got), len(items))
+	}
+	for i := range got {
+		if got[i].Value != items[i].Value {
+			t.Errorf("item %d = %d, want %d", i, got[i].Value, items[i].Value)
+		}
+	}
+}
+
+// testInteger is an INTEGER (0..255) list element
+type testInteger struct {
+	Value int64
+}
+
+func (ti *testInteger) Encode(aw *AperWriter) error {
+	return aw.WriteIntegerWith(ti.Value, Bounded(0, 255))
+}
+
+func (ti *testInteger) Decode(ar *AperReader) (err error) {
+	ti.Value, err = ar.ReadIntegerWith(Bounded(0, 255))
+	return
+}
+
+// NGAP-style INTEGER (0..65535, ...): values outside the root set the
+// extension bit and are encoded as unconstrained whole numbers
+func TestAperWriter_WriteInteger_Extension(t *testing.T) {
+	c := &Constraint{Lb: 0, Ub: 65535}
+	tests := []struct {
+		name     string
+		value    int64
+		expected string
+	}{
+		{"root lower bound", 0, "000000"},
+		{"root value", 100, "000064"},
+		{"root upper bound", 65535, "00ffff"},
+		{"extension above root", 65536, "8003010000"},
+		{"extension negative", -1, "8001ff"},
+		{"extension large", 1 << 40, "8006010000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			aw := NewWriter(&buf)
+			if err := aw.WriteInteger(tt.value, c, true); err != nil {
+				t.Fatalf("WriteInteger() error = %v", err)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteInteger() = %s, want %s", got, tt.expected)
+			}
+
+			ar := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ar.ReadInteger(c, true)
+			if err != nil {
+				t.Fatalf("ReadInteger() error = %v", err)
+			}
+			if value != tt.value {
+				t.Errorf("ReadInteger() = %d, want %d", value, tt.value)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := aw.WriteInteger(65536, c, false); err == nil {
+		t.Error("expected an error for a value outside a non-extensible constraint")
+	}
+	additions := Bounded(0, 10).Extend(Constraint{Lb: 20, Ub: 30})
+	if err := aw.WriteIntegerWith(25, additions); err != nil {
+		t.Errorf("WriteIntegerWith() error = %v for a value in an extension addition", err)
+	}
+	if err := aw.WriteIntegerWith(15, additions); err == nil {
+		t.Error("expected an error for a value outside the root and the extension additions")
+	}
+}
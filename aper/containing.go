@@ -0,0 +1,181 @@
+package aper
+
+import (
+	"bytes"
+
+	"github.com/lvdund/asn1go/utils"
+)
+
+// Marshal returns the complete encoding of v: the encoding padded to a whole
+// number of octets, or a single zero octet if it is empty (X.691 clause 11.1).
+func Marshal[T AperMarshaller](v T) ([]byte, error) {
+	return marshal(v, false)
+}
+
+// MarshalCanonical returns the complete CANONICAL-PER encoding of v.
+func MarshalCanonical[T AperMarshaller](v T) ([]byte, error) {
+	return marshal(v, true)
+}
+
+func marshal(v AperMarshaller, canonical bool) (b []byte, err error) {
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	aw.canonical = canonical
+	if err = v.Encode(aw); err != nil {
+		return
+	}
+	if err = aw.Close(); err != nil {
+		return
+	}
+	if b = buf.Bytes(); len(b) == 0 {
+		b = []byte{0}
+	}
+	return
+}
+
+// Unmarshal decodes v from its complete encoding b.
+func Unmarshal[T AperUnmarshaller](b []byte, v T) error {
+	return unmarshal(b, v, false)
+}
+
+// UnmarshalCanonical decodes v from its complete encoding b, which must be
+// the CANONICAL-PER encoding of v.
+func UnmarshalCanonical[T AperUnmarshaller](b []byte, v T) error {
+	return unmarshal(b, v, true)
+}
+
+func unmarshal(b []byte, v AperUnmarshaller, canonical bool) error {
+	ar := NewReader(bytes.NewReader(b))
+	ar.canonical = canonical
+	return v.Decode(ar)
+}
+
+// WriteContaining encodes an OCTET STRING (CONTAINING T) whose size is
+// constrained by c: v is encoded on its own with the aligned variant and its
+// complete encoding is written as the octet string.
+func WriteContaining[T AperMarshaller](v T, aw *AperWriter, c *Constraint, e bool) (err error) {
+	return WriteContainingWith(v, aw, NewPerConstraint(c, e))
+}
+
+// WriteContainingWith encodes an OCTET STRING (CONTAINING T) whose size is
+// constrained by pc. v is encoded in the mode of aw.
+func WriteContainingWith[T AperMarshaller](v T, aw *AperWriter, pc *PerConstraint) (err error) {
+	return WriteContainingFunc(v, func(v T) ([]byte, error) { return marshal(v, aw.canonical) }, aw, pc)
+}
+
+// WriteContainingFunc encodes an OCTET STRING (CONTAINING T) whose contained
+// value is encoded by marshal, such as uper.Marshal for an unaligned value
+// inside an aligned PDU.
+func WriteContainingFunc[T any](v T, marshal func(T) ([]byte, error), aw *AperWriter, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteContaining", err)
+	}()
+	var content []byte
+	if content, err = marshal(v); err != nil {
+		return
+	}
+	err = aw.WriteOctetStringWith(content, pc)
+	return
+}
+
+// ReadContaining decodes an OCTET STRING (CONTAINING T) written by
+// WriteContaining with the same constraint into v.
+func ReadContaining[T AperUnmarshaller](v T, ar *AperReader, c *Constraint, e bool) (err error) {
+	return ReadContainingWith(v, ar, NewPerConstraint(c, e))
+}
+
+// ReadContainingWith decodes an OCTET STRING (CONTAINING T) whose size is
+// constrained by pc into v. v is decoded in the mode of ar.
+func ReadContainingWith[T AperUnmarshaller](v T, ar *AperReader, pc *PerConstraint) (err error) {
+	return ReadContainingFunc(v, func(b []byte, v T) error { return unmarshal(b, v, ar.canonical) }, ar, pc)
+}
+
+// ReadContainingFunc decodes an OCTET STRING (CONTAINING T) whose contained
+// value is decoded by unmarshal, such as uper.Unmarshal.
+func ReadContainingFunc[T any](v T, unmarshal func([]byte, T) error, ar *AperReader, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("ReadContaining", err)
+	}()
+	var content []byte
+	if content, err = ar.ReadOctetStringWith(pc); err != nil {
+		return
+	}
+	err = unmarshal(content, v)
+	return
+}
+
+// EncodeBits encodes v on its own and returns the encoding with its exact
+// length in bits, without the padding of a complete encoding.
+func EncodeBits[T AperMarshaller](v T) (data []byte, nbits uint, err error) {
+	return encodeBits(v, false)
+}
+
+func encodeBits(v AperMarshaller, canonical bool) (data []byte, nbits uint, err error) {
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	aw.canonical = canonical
+	if err = v.Encode(aw); err != nil {
+		return
+	}
+	nbits = uint(8*buf.Len()) + uint(aw.index)
+	if err = aw.flush(); err != nil {
+		return
+	}
+	data = buf.Bytes()
+	return
+}
+
+// NewReaderBits returns a reader of the first nbits bits of data. Reading
+// past them fails with ErrIncomplete.
+func NewReaderBits(data []byte, nbits uint) *AperReader {
+	ar := NewReader(bytes.NewReader(data))
+	ar.limited = true
+	ar.remaining = min(uint64(nbits), uint64(8*len(data)))
+	return ar
+}
+
+// WriteBitStringContaining encodes a BIT STRING (CONTAINING T) whose size is
+// constrained by c: the bit string holds the encoding of v with its exact
+// length in bits.
+func WriteBitStringContaining[T AperMarshaller](v T, aw *AperWriter, c *Constraint, e bool) (err error) {
+	return WriteBitStringContainingWith(v, aw, NewPerConstraint(c, e))
+}
+
+// WriteBitStringContainingWith encodes a BIT STRING (CONTAINING T) whose size
+// is constrained by pc.
+func WriteBitStringContainingWith[T AperMarshaller](v T, aw *AperWriter, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteBitStringContaining", err)
+	}()
+	var content []byte
+	var nbits uint
+	if content, nbits, err = encodeBits(v, aw.canonical); err != nil {
+		return
+	}
+	err = aw.WriteBitStringWith(content, nbits, pc)
+	return
+}
+
+// ReadBitStringContaining decodes a BIT STRING (CONTAINING T) written by
+// WriteBitStringContaining with the same constraint into v. Decoding v may
+// not read past the bits of the string.
+func ReadBitStringContaining[T AperUnmarshaller](v T, ar *AperReader, c *Constraint, e bool) (err error) {
+	return ReadBitStringContainingWith(v, ar, NewPerConstraint(c, e))
+}
+
+// ReadBitStringContainingWith decodes a BIT STRING (CONTAINING T) whose size
+// is constrained by pc into v.
+func ReadBitStringContainingWith[T AperUnmarshaller](v T, ar *AperReader, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("ReadBitStringContaining", err)
+	}()
+	var content []byte
+	var nbits uint
+	if content, nbits, err = ar.ReadBitStringWith(pc); err != nil {
+		return
+	}
+	r := NewReaderBits(content, nbits)
+	r.canonical = ar.canonical
+	err = v.Decode(r)
+	return
+}
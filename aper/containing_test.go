@@ -0,0 +1,144 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/lvdund/asn1go/uper"
+)
+
+var pairValue = &Constraint{Lb: 0, Ub: 255}
+
+// testPair is SEQUENCE { flag BOOLEAN, value INTEGER (0..255) }
+type testPair struct {
+	Flag  bool
+	Value int64
+}
+
+func (p *testPair) Encode(aw *AperWriter) (err error) {
+	if err = aw.WriteBool(p.Flag); err != nil {
+		return
+	}
+	return aw.WriteInteger(p.Value, pairValue, false)
+}
+
+func (p *testPair) Decode(ar *AperReader) (err error) {
+	if p.Flag, err = ar.ReadBool(); err != nil {
+		return
+	}
+	p.Value, err = ar.ReadInteger(pairValue, false)
+	return
+}
+
+// unalignedPair is testPair encoded with the unaligned variant, as an RRC
+// container inside an NGAP PDU
+type unalignedPair testPair
+
+func (p *unalignedPair) Encode(uw *uper.UperWriter) (err error) {
+	if err = uw.WriteBool(p.Flag); err != nil {
+		return
+	}
+	return uw.WriteInteger(p.Value, &uper.Constraint{Lb: 0, Ub: 255}, false)
+}
+
+func (p *unalignedPair) Decode(ur *uper.UperReader) (err error) {
+	if p.Flag, err = ur.ReadBool(); err != nil {
+		return
+	}
+	p.Value, err = ur.ReadInteger(&uper.Constraint{Lb: 0, Ub: 255}, false)
+	return
+}
+
+func TestWriteContaining(t *testing.T) {
+	got := encodeTest(t, func(aw *AperWriter) error {
+		return WriteContaining(&testPair{Flag: true, Value: 5}, aw, nil, false)
+	})
+	if want := "028005"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteContaining() = %x, want %s", got, want)
+	}
+	var v testPair
+	if err := ReadContaining(&v, NewReader(bytes.NewReader(got)), nil, false); err != nil {
+		t.Fatalf("ReadContaining() error = %v", err)
+	}
+	if v != (testPair{Flag: true, Value: 5}) {
+		t.Errorf("ReadContaining() = %+v", v)
+	}
+
+	// an empty inner encoding is carried as a single zero octet
+	got = encodeTest(t, func(aw *AperWriter) error {
+		return WriteContaining(&ConstrainedInteger{C: &Constraint{Lb: 3, Ub: 3}, Value: 3}, aw, nil, false)
+	})
+	if want := "0100"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteContaining(empty) = %x, want %s", got, want)
+	}
+
+	err := WriteContaining(&testPair{}, NewWriter(new(bytes.Buffer)), &Constraint{Lb: 0, Ub: 1}, false)
+	if err == nil {
+		t.Error("expected an error for a contained value exceeding the SIZE constraint")
+	}
+}
+
+func TestWriteContainingFunc_Unaligned(t *testing.T) {
+	got := encodeTest(t, func(aw *AperWriter) error {
+		return WriteContainingFunc(&unalignedPair{Flag: true, Value: 5}, uper.Marshal[*unalignedPair], aw, nil)
+	})
+	if want := "028280"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteContainingFunc() = %x, want %s", got, want)
+	}
+	var v unalignedPair
+	if err := ReadContainingFunc(&v, uper.Unmarshal[*unalignedPair], NewReader(bytes.NewReader(got)), nil); err != nil {
+		t.Fatalf("ReadContainingFunc() error = %v", err)
+	}
+	if v != (unalignedPair{Flag: true, Value: 5}) {
+		t.Errorf("ReadContainingFunc() = %+v", v)
+	}
+}
+
+func TestEncodeBits(t *testing.T) {
+	data, nbits, err := EncodeBits(&testPair{Flag: true, Value: 5})
+	if err != nil {
+		t.Fatalf("EncodeBits() error = %v", err)
+	}
+	if hex.EncodeToString(data) != "8005" || nbits != 16 {
+		t.Errorf("EncodeBits() = %x, %d, want 8005, 16", data, nbits)
+	}
+
+	var v testPair
+	if err := v.Decode(NewReaderBits(data, nbits)); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if err := new(testPair).Decode(NewReaderBits(data, nbits-4)); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("Decode() of %d bits error = %v, want ErrIncomplete", nbits-4, err)
+	}
+}
+
+func TestWriteBitStringContaining(t *testing.T) {
+	got := encodeTest(t, func(aw *AperWriter) error {
+		return WriteBitStringContaining(&testPair{Flag: true, Value: 5}, aw, nil, false)
+	})
+	if want := "108005"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteBitStringContaining() = %x, want %s", got, want)
+	}
+	var v testPair
+	if err := ReadBitStringContaining(&v, NewReader(bytes.NewReader(got)), nil, false); err != nil {
+		t.Fatalf("ReadBitStringContaining() error = %v", err)
+	}
+	if v != (testPair{Flag: true, Value: 5}) {
+		t.Errorf("ReadBitStringContaining() = %+v", v)
+	}
+
+	// a string one bit too short for the contained value
+	short := encodeTest(t, func(aw *AperWriter) error {
+		data, nbits, err := EncodeBits(&testPair{Flag: true, Value: 5})
+		if err != nil {
+			return err
+		}
+		return aw.WriteBitString(data, nbits-1, nil, false)
+	})
+	err := ReadBitStringContaining(new(testPair), NewReader(bytes.NewReader(short)), nil, false)
+	if !errors.Is(err, ErrIncomplete) {
+		t.Errorf("ReadBitStringContaining() error = %v, want ErrIncomplete", err)
+	}
+}
@@ -0,0 +1,86 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestAperWriter_WriteNamedEnum(t *testing.T) {
+	// { a(0), b(5), c(10), ..., d(3) } declared out of order
+	d := NewEnumerationDescriptor([]int64{10, 0, 5}, true, 3)
+	tests := []struct {
+		name     string
+		value    int64
+		expected string
+	}{
+		{"a", 0, "00"},
+		{"b", 5, "20"},
+		{"c", 10, "40"},
+		{"d (extension)", 3, "80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			aw := NewWriter(&buf)
+			if err := aw.WriteNamedEnum(tt.value, d); err != nil {
+				t.Fatalf("WriteNamedEnum() error = %v", err)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteNamedEnum() = %s, want %s", got, tt.expected)
+			}
+
+			ar := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ar.ReadNamedEnum(d)
+			if err != nil {
+				t.Fatalf("ReadNamedEnum() error = %v", err)
+			}
+			if value != tt.value {
+				t.Errorf("ReadNamedEnum() = %d, want %d", value, tt.value)
+			}
+		})
+	}
+
+	if err := NewWriter(new(bytes.Buffer)).WriteNamedEnum(7, d); !errors.Is(err, ErrUnknownValue) {
+		t.Errorf("WriteNamedEnum(7) error = %v, want ErrUnknownValue", err)
+	}
+	if err := NewWriter(new(bytes.Buffer)).WriteNamedEnum(3, NewEnumerationDescriptor([]int64{0, 5}, false)); !errors.Is(err, ErrUnknownValue) {
+		t.Errorf("WriteNamedEnum() of an addition on a non-extensible type error = %v, want ErrUnknownValue", err)
+	}
+}
+
+func TestAperReader_ReadNamedEnum_UnknownExtension(t *testing.T) {
+	// a newer peer knows { a(0), b(5), c(10), ..., d(3), e(4) }
+	newer := NewEnumerationDescriptor([]int64{0, 5, 10}, true, 3, 4)
+	older := NewEnumerationDescriptor([]int64{0, 5, 10}, true, 3)
+
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := aw.WriteNamedEnum(4, newer); err != nil {
+		t.Fatalf("WriteNamedEnum() error = %v", err)
+	}
+	if err := aw.WriteBool(true); err != nil {
+		t.Fatalf("WriteBool() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	ar := NewReader(bytes.NewReader(buf.Bytes()))
+	_, err := ar.ReadNamedEnum(older)
+	var unknown *UnknownExtensionError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("ReadNamedEnum() error = %v, want *UnknownExtensionError", err)
+	}
+	if unknown.Index != 1 {
+		t.Errorf("UnknownExtensionError.Index = %d, want 1", unknown.Index)
+	}
+	// the unknown value has been consumed, the next field can still be read
+	if bit, err := ar.ReadBool(); err != nil || !bit {
+		t.Errorf("ReadBool() after unknown extension = %v, %v", bit, err)
+	}
+}
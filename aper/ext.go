@@ -0,0 +1,197 @@
+package aper
+
+import "github.com/lvdund/asn1go/utils"
+
+// handle extension additions of an extensible SEQUENCE, e.g.
+// PersonnelRecord ::= SEQUENCE {
+//     age0    INTEGER (1..8),
+//     age1    INTEGER (1..8) OPTIONAL,
+//     ...,
+//     [[
+//     age2    INTEGER (1..2)  OPTIONAL,
+//     age4    INTEGER (1..32) OPTIONAL
+//     ]],
+//     [[
+//     age5    INTEGER (1..2)  OPTIONAL,
+//     age6    INTEGER (1..32) OPTIONAL
+//     ]]
+// }
+//
+// When the extension bit of the preamble is set, the root fields are followed
+// by a bitmap with one bit per addition (a group counts as one addition), then
+// by each present addition encoded as an open type (X.691 clause 19.7-19.9).
+
+// WriteExtBitMap writes the presence bitmap of the extension additions; its
+// length is a normally small length.
+func (aw *AperWriter) WriteExtBitMap(extBitmap []bool) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteExtBitMap", err)
+	}()
+	n := uint64(len(extBitmap))
+	if n == 0 {
+		err = ErrInvalidLength
+		return
+	}
+	if n <= POW_6 {
+		if err = aw.WriteBool(Zero); err != nil {
+			return
+		}
+		err = aw.writeValue(n-1, 6)
+	} else {
+		if err = aw.WriteBool(One); err != nil {
+			return
+		}
+		err = aw.writeLength(0, n)
+	}
+	if err != nil {
+		return
+	}
+	for _, bit := range extBitmap {
+		if err = aw.WriteBool(bit); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ReadExtBitMap reads the presence bitmap written by WriteExtBitMap.
+func (ar *AperReader) ReadExtBitMap() (extBitmap []bool, err error) {
+	defer func() {
+		err = utils.WrapError("ReadExtBitMap", err)
+	}()
+	var isLarge bool
+	if isLarge, err = ar.ReadBool(); err != nil {
+		return
+	}
+	var n uint64
+	if !isLarge {
+		if n, err = ar.readValue(6); err != nil {
+			return
+		}
+		n++
+	} else {
+		var more bool
+		if n, more, err = ar.readLength(0); err != nil {
+			return
+		}
+		if more || n == 0 {
+			err = ErrInvalidLength
+			return
+		}
+	}
+	extBitmap = make([]bool, n)
+	for i := range extBitmap {
+		if extBitmap[i], err = ar.ReadBool(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// RawExtension is an extension addition kept as its open-type octets, at
+// its position in the extension bitmap.
+type RawExtension struct {
+	Index int
+	Data  []byte
+}
+
+// RawExtensions holds the extension additions a decoder has no definition
+// for, together with the length of the bitmap they came with, so that a
+// value received from a newer peer can be re-encoded unchanged.
+type RawExtensions struct {
+	BitmapLen int
+	Additions []RawExtension
+}
+
+// IsEmpty reports whether no unknown addition was kept.
+func (raw *RawExtensions) IsEmpty() bool {
+	return raw == nil || len(raw.Additions) == 0
+}
+
+// RawOpenType is the content of an open type kept as octets, e.g. the value
+// of an unknown CHOICE alternative. Writing it with WriteOpenTypeValue
+// reproduces the original open type.
+type RawOpenType []byte
+
+func (raw RawOpenType) Encode(aw *AperWriter) error {
+	return aw.writeBytes(raw)
+}
+
+// WriteExtensionAdditions writes the bitmap and the open types of the
+// extension additions, in definition order. A nil entry is an absent
+// addition; a group is a single entry that encodes its own preamble. The
+// unknown additions in raw, if any, are written back at their positions.
+func (aw *AperWriter) WriteExtensionAdditions(additions []AperMarshaller, raw *RawExtensions) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteExtensionAdditions", err)
+	}()
+	n := len(additions)
+	unknown := make(map[int][]byte)
+	if raw != nil && raw.BitmapLen > 0 { //keep the length of the received bitmap
+		n = raw.BitmapLen
+		for _, addition := range raw.Additions {
+			unknown[addition.Index] = addition.Data
+			n = max(n, addition.Index+1)
+		}
+		for i, addition := range additions {
+			if addition != nil {
+				n = max(n, i+1)
+			}
+		}
+	}
+	extBitmap := make([]bool, n)
+	for i := range extBitmap {
+		_, extBitmap[i] = unknown[i]
+		if i < len(additions) && additions[i] != nil {
+			extBitmap[i] = true
+		}
+	}
+	if err = aw.WriteExtBitMap(extBitmap); err != nil {
+		return
+	}
+	for i, present := range extBitmap {
+		if !present {
+			continue
+		}
+		if i < len(additions) && additions[i] != nil {
+			err = aw.WriteOpenTypeValue(additions[i])
+		} else {
+			err = aw.WriteOpenType(unknown[i])
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ReadExtensionAdditions reads the extension additions written by
+// WriteExtensionAdditions. Each present addition is decoded into the entry at
+// its position; additions beyond the known ones, or with a nil entry, are
+// returned in raw. The returned bitmap tells which additions were present.
+func (ar *AperReader) ReadExtensionAdditions(additions []AperUnmarshaller) (extBitmap []bool, raw RawExtensions, err error) {
+	defer func() {
+		err = utils.WrapError("ReadExtensionAdditions", err)
+	}()
+	if extBitmap, err = ar.ReadExtBitMap(); err != nil {
+		return
+	}
+	raw.BitmapLen = len(extBitmap)
+	for i, present := range extBitmap {
+		if !present {
+			continue
+		}
+		if i < len(additions) && additions[i] != nil {
+			err = ar.ReadOpenTypeValue(additions[i])
+		} else {
+			var data []byte
+			if data, err = ar.ReadOpenType(); err == nil {
+				raw.Additions = append(raw.Additions, RawExtension{Index: i, Data: data})
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,221 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+//	PersonnelRecord ::= SEQUENCE {
+//	    age0 INTEGER (1..8),
+//	    age1 INTEGER (1..8) OPTIONAL,
+//	    ...,
+//	    [[ age2 INTEGER (1..2) OPTIONAL, age4 INTEGER (1..32) OPTIONAL ]],
+//	    [[ age5 INTEGER (1..2) OPTIONAL, age6 INTEGER (1..32) OPTIONAL ]]
+//	}
+type personnelRecord struct {
+	age0   int64
+	age1   *int64
+	group1 *personnelGroup
+	group2 *personnelGroup
+}
+
+// an extension group of two optional fields (1..2) and (1..32)
+type personnelGroup struct {
+	first  *int64
+	second *int64
+}
+
+func (g *personnelGroup) Encode(aw *AperWriter) (err error) {
+	if err = aw.WriteBool(g.first != nil); err != nil {
+		return
+	}
+	if err = aw.WriteBool(g.second != nil); err != nil {
+		return
+	}
+	if g.first != nil {
+		if err = aw.WriteInteger(*g.first, &Constraint{Lb: 1, Ub: 2}, false); err != nil {
+			return
+		}
+	}
+	if g.second != nil {
+		err = aw.WriteInteger(*g.second, &Constraint{Lb: 1, Ub: 32}, false)
+	}
+	return
+}
+
+func (g *personnelGroup) Decode(ar *AperReader) (err error) {
+	var firstPresent, secondPresent bool
+	if firstPresent, err = ar.ReadBool(); err != nil {
+		return
+	}
+	if secondPresent, err = ar.ReadBool(); err != nil {
+		return
+	}
+	if firstPresent {
+		var v int64
+		if v, err = ar.ReadInteger(&Constraint{Lb: 1, Ub: 2}, false); err != nil {
+			return
+		}
+		g.first = &v
+	}
+	if secondPresent {
+		var v int64
+		if v, err = ar.ReadInteger(&Constraint{Lb: 1, Ub: 32}, false); err != nil {
+			return
+		}
+		g.second = &v
+	}
+	return
+}
+
+func (r *personnelRecord) Encode(aw *AperWriter) (err error) {
+	var additions []AperMarshaller
+	if r.group1 != nil || r.group2 != nil {
+		additions = []AperMarshaller{nil, nil}
+		if r.group1 != nil {
+			additions[0] = r.group1
+		}
+		if r.group2 != nil {
+			additions[1] = r.group2
+		}
+	}
+	if err = aw.WriteBool(additions != nil); err != nil {
+		return
+	}
+	if err = aw.WriteBool(r.age1 != nil); err != nil {
+		return
+	}
+	if err = aw.WriteInteger(r.age0, &Constraint{Lb: 1, Ub: 8}, false); err != nil {
+		return
+	}
+	if r.age1 != nil {
+		if err = aw.WriteInteger(*r.age1, &Constraint{Lb: 1, Ub: 8}, false); err != nil {
+			return
+		}
+	}
+	if additions != nil {
+		err = aw.WriteExtensionAdditions(additions, nil)
+	}
+	return
+}
+
+func (r *personnelRecord) Decode(ar *AperReader) (err error) {
+	var extended, age1Present bool
+	if extended, err = ar.ReadBool(); err != nil {
+		return
+	}
+	if age1Present, err = ar.ReadBool(); err != nil {
+		return
+	}
+	if r.age0, err = ar.ReadInteger(&Constraint{Lb: 1, Ub: 8}, false); err != nil {
+		return
+	}
+	if age1Present {
+		var v int64
+		if v, err = ar.ReadInteger(&Constraint{Lb: 1, Ub: 8}, false); err != nil {
+			return
+		}
+		r.age1 = &v
+	}
+	if !extended {
+		return
+	}
+	group1, group2 := new(personnelGroup), new(personnelGroup)
+	var present []bool
+	if present, _, err = ar.ReadExtensionAdditions([]AperUnmarshaller{group1, group2}); err != nil {
+		return
+	}
+	if len(present) > 0 && present[0] {
+		r.group1 = group1
+	}
+	if len(present) > 1 && present[1] {
+		r.group2 = group2
+	}
+	return
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestAperWriter_WriteExtensionAdditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   personnelRecord
+		expected string
+	}{
+		{
+			name:     "root only",
+			record:   personnelRecord{age0: 5, age1: int64Ptr(6)},
+			expected: "65",
+		},
+		{
+			name: "root and both groups",
+			record: personnelRecord{age0: 5, age1: int64Ptr(6),
+				group1: &personnelGroup{int64Ptr(2), int64Ptr(30)},
+				group2: &personnelGroup{int64Ptr(1), int64Ptr(17)}},
+			expected: "e5038001fd01d0",
+		},
+		{
+			name: "second group only",
+			record: personnelRecord{age0: 1,
+				group2: &personnelGroup{nil, int64Ptr(32)}},
+			expected: "8014017e",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			aw := NewWriter(&buf)
+			if err := tt.record.Encode(aw); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("Encode() = %s, want %s", got, tt.expected)
+			}
+
+			var got personnelRecord
+			if err := got.Decode(NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got.age0 != tt.record.age0 || (got.age1 == nil) != (tt.record.age1 == nil) ||
+				(got.group1 == nil) != (tt.record.group1 == nil) || (got.group2 == nil) != (tt.record.group2 == nil) {
+				t.Fatalf("Decode() = %+v, want %+v", got, tt.record)
+			}
+			if tt.record.group2 != nil && *got.group2.second != *tt.record.group2.second {
+				t.Errorf("Decode() group2.second = %d, want %d", *got.group2.second, *tt.record.group2.second)
+			}
+		})
+	}
+}
+
+func TestAperWriter_WriteExtBitMap(t *testing.T) {
+	for _, n := range []int{1, 64, 65, 100, 200} {
+		bitmap := make([]bool, n)
+		bitmap[0], bitmap[n-1] = true, true
+
+		var buf bytes.Buffer
+		aw := NewWriter(&buf)
+		if err := aw.WriteExtBitMap(bitmap); err != nil {
+			t.Fatalf("WriteExtBitMap(%d) error = %v", n, err)
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if n > 64 && n < 128 && !bytes.HasPrefix(buf.Bytes(), []byte{0x80, byte(n)}) {
+			t.Errorf("WriteExtBitMap(%d) = %x, want a length octet after the aligned flag", n, buf.Bytes())
+		}
+
+		got, err := NewReader(bytes.NewReader(buf.Bytes())).ReadExtBitMap()
+		if err != nil {
+			t.Fatalf("ReadExtBitMap(%d) error = %v", n, err)
+		}
+		if len(got) != n || !got[0] || !got[n-1] {
+			t.Errorf("ReadExtBitMap(%d) returned %d bits", n, len(got))
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package aper
+
+import (
+	"github.com/lvdund/asn1go/utils"
+)
+
+// Alternatives of the identification CHOICE of EMBEDDED PDV and CHARACTER
+// STRING (X.680 clauses 36.5 and 44.5), as CHOICE indexes.
+const (
+	IdentificationSyntaxes uint64 = iota + 1
+	IdentificationSyntax
+	IdentificationPresentationContextID
+	IdentificationContextNegotiation
+	IdentificationTransferSyntax
+	IdentificationFixed
+)
+
+// Identification tells how the abstract and transfer syntaxes of an embedded
+// value are identified. The fields used depend on Choice:
+//
+//	syntaxes                 Abstract, Transfer
+//	syntax                   Abstract
+//	presentation-context-id  PresentationContextID
+//	context-negotiation      PresentationContextID, Transfer
+//	transfer-syntax          Transfer
+//	fixed                    none
+type Identification struct {
+	Choice                uint64
+	Abstract              ObjectIdentifier
+	Transfer              ObjectIdentifier
+	PresentationContextID int64
+}
+
+func (id *Identification) Encode(aw *AperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("Identification", err)
+	}()
+	if err = aw.WriteChoice(id.Choice, IdentificationFixed-1, false); err != nil {
+		return
+	}
+	switch id.Choice {
+	case IdentificationSyntaxes:
+		if err = aw.WriteObjectIdentifier(id.Abstract); err != nil {
+			return
+		}
+		err = aw.WriteObjectIdentifier(id.Transfer)
+	case IdentificationSyntax:
+		err = aw.WriteObjectIdentifier(id.Abstract)
+	case IdentificationPresentationContextID:
+		err = aw.WriteInteger(id.PresentationContextID, nil, false)
+	case IdentificationContextNegotiation:
+		if err = aw.WriteInteger(id.PresentationContextID, nil, false); err != nil {
+			return
+		}
+		err = aw.WriteObjectIdentifier(id.Transfer)
+	case IdentificationTransferSyntax:
+		err = aw.WriteObjectIdentifier(id.Transfer)
+	}
+	return
+}
+
+func (id *Identification) Decode(ar *AperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("Identification", err)
+	}()
+	*id = Identification{}
+	if id.Choice, err = ar.ReadChoice(IdentificationFixed-1, false); err != nil {
+		return
+	}
+	switch id.Choice {
+	case IdentificationSyntaxes:
+		if id.Abstract, err = ar.ReadObjectIdentifier(); err != nil {
+			return
+		}
+		id.Transfer, err = ar.ReadObjectIdentifier()
+	case IdentificationSyntax:
+		id.Abstract, err = ar.ReadObjectIdentifier()
+	case IdentificationPresentationContextID:
+		id.PresentationContextID, err = ar.ReadInteger(nil, false)
+	case IdentificationContextNegotiation:
+		if id.PresentationContextID, err = ar.ReadInteger(nil, false); err != nil {
+			return
+		}
+		id.Transfer, err = ar.ReadObjectIdentifier()
+	case IdentificationTransferSyntax:
+		id.Transfer, err = ar.ReadObjectIdentifier()
+	case IdentificationFixed:
+	default:
+		err = ErrUnknownChoice
+	}
+	return
+}
+
+// Alternatives of the encoding CHOICE of EXTERNAL, as CHOICE indexes.
+const (
+	ExternalSingleASN1Type uint64 = iota + 1
+	ExternalOctetAligned
+	ExternalArbitrary
+)
+
+// External is an EXTERNAL value, encoded as the SEQUENCE of X.691 clause 29:
+//
+//	SEQUENCE {
+//		direct-reference      OBJECT IDENTIFIER OPTIONAL,
+//		indirect-reference    INTEGER OPTIONAL,
+//		data-value-descriptor ObjectDescriptor OPTIONAL,
+//		encoding CHOICE {
+//			single-ASN1-type [0] ABSTRACT-SYNTAX.&Type,
+//			octet-aligned    [1] IMPLICIT OCTET STRING,
+//			arbitrary        [2] IMPLICIT BIT STRING } }
+//
+// The syntax identification is a direct reference, the presentation context
+// one an indirect reference and context negotiation both. DataValue holds the
+// open type octets of a single ASN.1 type, the octets of an octet-aligned
+// value or the NumBits bits of an arbitrary one; see DecodeValue.
+type External struct {
+	DirectReference     ObjectIdentifier //nil if absent
+	IndirectReference   *int64
+	DataValueDescriptor *string
+	Encoding            uint64
+	DataValue           []byte
+	NumBits             uint64 //arbitrary only; 0 means all the bits of DataValue
+}
+
+func (x *External) Encode(aw *AperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("External", err)
+	}()
+	for _, present := range []bool{x.DirectReference != nil, x.IndirectReference != nil, x.DataValueDescriptor != nil} {
+		if err = aw.WriteBool(present); err != nil {
+			return
+		}
+	}
+	if x.DirectReference != nil {
+		if err = aw.WriteObjectIdentifier(x.DirectReference); err != nil {
+			return
+		}
+	}
+	if x.IndirectReference != nil {
+		if err = aw.WriteInteger(*x.IndirectReference, nil, false); err != nil {
+			return
+		}
+	}
+	if x.DataValueDescriptor != nil { //an ObjectDescriptor is encoded like an OCTET STRING
+		if err = aw.WriteOctetString([]byte(*x.DataValueDescriptor), nil, false); err != nil {
+			return
+		}
+	}
+	if err = aw.WriteChoice(x.Encoding, ExternalArbitrary-1, false); err != nil {
+		return
+	}
+	switch x.Encoding {
+	case ExternalSingleASN1Type:
+		err = aw.WriteOpenType(x.DataValue)
+	case ExternalOctetAligned:
+		err = aw.WriteOctetString(x.DataValue, nil, false)
+	case ExternalArbitrary:
+		nbits := x.NumBits
+		if nbits == 0 {
+			nbits = uint64(8 * len(x.DataValue))
+		}
+		err = aw.WriteBitString(x.DataValue, uint(nbits), nil, false)
+	}
+	return
+}
+
+func (x *External) Decode(ar *AperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("External", err)
+	}()
+	*x = External{}
+	var present [3]bool
+	for i := range present {
+		if present[i], err = ar.ReadBool(); err != nil {
+			return
+		}
+	}
+	if present[0] {
+		if x.DirectReference, err = ar.ReadObjectIdentifier(); err != nil {
+			return
+		}
+	}
+	if present[1] {
+		var v int64
+		if v, err = ar.ReadInteger(nil, false); err != nil {
+			return
+		}
+		x.IndirectReference = &v
+	}
+	if present[2] {
+		var content []byte
+		if content, err = ar.ReadOctetString(nil, false); err != nil {
+			return
+		}
+		descriptor := string(content)
+		x.DataValueDescriptor = &descriptor
+	}
+	if x.Encoding, err = ar.ReadChoice(ExternalArbitrary-1, false); err != nil {
+		return
+	}
+	switch x.Encoding {
+	case ExternalSingleASN1Type:
+		x.DataValue, err = ar.ReadOpenType()
+	case ExternalOctetAligned:
+		x.DataValue, err = ar.ReadOctetString(nil, false)
+	case ExternalArbitrary:
+		var nbits uint
+		x.DataValue, nbits, err = ar.ReadBitString(nil, false)
+		x.NumBits = uint64(nbits)
+	default:
+		err = ErrUnknownChoice
+	}
+	return
+}
+
+// DecodeValue decodes the data value into v with the aligned variant. A value
+// in another encoding can be decoded from DataValue directly.
+func (x *External) DecodeValue(v AperUnmarshaller) error {
+	if x.Encoding == ExternalArbitrary && x.NumBits > 0 {
+		return v.Decode(NewReaderBits(x.DataValue, uint(x.NumBits)))
+	}
+	return Unmarshal(x.DataValue, v)
+}
+
+// EmbeddedPDV is an EMBEDDED PDV value, encoded as the SEQUENCE of X.680
+// clause 36.5 without its data-value-descriptor, which is always absent:
+//
+//	SEQUENCE {
+//		identification Identification,
+//		data-value     OCTET STRING }
+type EmbeddedPDV struct {
+	Identification Identification
+	DataValue      []byte
+}
+
+func (p *EmbeddedPDV) Encode(aw *AperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("EmbeddedPDV", err)
+	}()
+	if err = p.Identification.Encode(aw); err != nil {
+		return
+	}
+	err = aw.WriteOctetString(p.DataValue, nil, false)
+	return
+}
+
+func (p *EmbeddedPDV) Decode(ar *AperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("EmbeddedPDV", err)
+	}()
+	if err = p.Identification.Decode(ar); err != nil {
+		return
+	}
+	p.DataValue, err = ar.ReadOctetString(nil, false)
+	return
+}
+
+// DecodeValue decodes the data value into v with the aligned variant. A value
+// in another transfer syntax can be decoded from DataValue directly.
+func (p *EmbeddedPDV) DecodeValue(v AperUnmarshaller) error {
+	return Unmarshal(p.DataValue, v)
+}
+
+// CharacterString is an unrestricted CHARACTER STRING value, encoded as the
+// SEQUENCE of X.680 clause 44.5 without its data-value-descriptor:
+//
+//	SEQUENCE {
+//		identification Identification,
+//		string-value   OCTET STRING }
+type CharacterString struct {
+	Identification Identification
+	StringValue    []byte
+}
+
+func (s *CharacterString) Encode(aw *AperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("CharacterString", err)
+	}()
+	if err = s.Identification.Encode(aw); err != nil {
+		return
+	}
+	err = aw.WriteOctetString(s.StringValue, nil, false)
+	return
+}
+
+func (s *CharacterString) Decode(ar *AperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("CharacterString", err)
+	}()
+	if err = s.Identification.Decode(ar); err != nil {
+		return
+	}
+	s.StringValue, err = ar.ReadOctetString(nil, false)
+	return
+}
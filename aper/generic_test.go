@@ -0,0 +1,135 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// INTEGER (0..65535, ...)
+type portRange struct{}
+
+func (portRange) Constraint() *PerConstraint { return Bounded(0, 65535).Extend() }
+
+// SIZE (4)
+type fourOctets struct{}
+
+func (fourOctets) Constraint() *PerConstraint { return Bounded(4, 4) }
+
+// SIZE (1..16)
+type upTo16 struct{}
+
+func (upTo16) Constraint() *PerConstraint { return Bounded(1, 16) }
+
+// INTEGER (1..MAX)
+type positive struct{}
+
+func (positive) Constraint() *PerConstraint { return AtLeast(1) }
+
+// INTEGER (0..7, ..., 100..200)
+type withAdditions struct{}
+
+func (withAdditions) Constraint() *PerConstraint {
+	return Bounded(0, 7).Extend(Constraint{Lb: 100, Ub: 200})
+}
+
+// SIZE (1..MAX)
+type nonEmpty struct{}
+
+func (nonEmpty) Constraint() *PerConstraint { return AtLeast(1) }
+
+func TestConstrainedTypes(t *testing.T) {
+	port := ConstrainedInt[portRange](8080)
+	addr := SizedOctets[fourOctets]{10, 0, 0, 1}
+	var flags SizedBits[upTo16]
+	(*BitString)(&flags).FromUint64(0x5, 3)
+
+	for _, v := range []interface{ Validate() error }{&port, &addr, &flags} {
+		if err := v.Validate(); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	for _, ie := range []IE{&port, &addr, &flags} {
+		if err := ie.Encode(aw); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	want := new(bytes.Buffer)
+	ref := NewWriter(want)
+	_ = ref.WriteInteger(8080, &Constraint{Lb: 0, Ub: 65535}, true)
+	_ = ref.WriteOctetString([]byte{10, 0, 0, 1}, &Constraint{Lb: 4, Ub: 4}, false)
+	_ = ref.WriteBitString([]byte{0xa0}, 3, &Constraint{Lb: 1, Ub: 16}, false)
+	_ = ref.Close()
+	if got := hex.EncodeToString(buf.Bytes()); got != hex.EncodeToString(want.Bytes()) {
+		t.Errorf("Encode() = %s, want %x", got, want.Bytes())
+	}
+
+	var (
+		gotPort  ConstrainedInt[portRange]
+		gotAddr  SizedOctets[fourOctets]
+		gotFlags SizedBits[upTo16]
+	)
+	ar := NewReader(bytes.NewReader(buf.Bytes()))
+	for _, ie := range []IE{&gotPort, &gotAddr, &gotFlags} {
+		if err := ie.Decode(ar); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+	}
+	if gotPort != port || !bytes.Equal(gotAddr, addr) || BitString(gotFlags).String() != "'101'B" {
+		t.Errorf("Decode() = %d, %x, %s", gotPort, gotAddr, BitString(gotFlags))
+	}
+
+	big := ConstrainedInt[portRange](70000)
+	count := ConstrainedInt[positive](1 << 40)
+	zero := ConstrainedInt[positive](0)
+	addition := ConstrainedInt[withAdditions](150)
+	beyond := ConstrainedInt[withAdditions](300)
+	long := SizedOctets[nonEmpty](make([]byte, 70000))
+	tests := []struct {
+		name string
+		ie   interface{ Validate() error }
+		want error
+	}{
+		{"short fixed-size value", &SizedOctets[fourOctets]{1, 2}, ErrFixedLength},
+		{"long fixed-size value", &SizedOctets[fourOctets]{1, 2, 3, 4, 5}, ErrFixedLength},
+		{"empty value below the size range", &SizedBits[upTo16]{}, ErrInextensible},
+		{"extension value", &big, nil},
+		{"large semi-constrained value", &count, nil},
+		{"value below a semi-constrained range", &zero, ErrInextensible},
+		{"extension addition value", &addition, nil},
+		{"value outside the extension additions", &beyond, ErrInextensible},
+		{"long value of an unbounded size", &long, nil},
+		{"empty value of an unbounded size", &SizedOctets[nonEmpty]{}, ErrInextensible},
+	}
+	for _, tt := range tests {
+		if err := tt.ie.Validate(); !errors.Is(err, tt.want) {
+			t.Errorf("Validate() error = %v for a %s, want %v", err, tt.name, tt.want)
+		}
+	}
+
+	// a semi-constrained value and an extension addition survive a round trip
+	encoded := encodeTest(t, func(aw *AperWriter) error {
+		if err := count.Encode(aw); err != nil {
+			return err
+		}
+		return addition.Encode(aw)
+	})
+	var (
+		gotCount    ConstrainedInt[positive]
+		gotAddition ConstrainedInt[withAdditions]
+	)
+	ar = NewReader(bytes.NewReader(encoded))
+	if err := gotCount.Decode(ar); err != nil || gotCount != count {
+		t.Errorf("Decode() = %d, %v, want %d", gotCount, err, count)
+	}
+	if err := gotAddition.Decode(ar); err != nil || gotAddition != addition {
+		t.Errorf("Decode() = %d, %v, want %d", gotAddition, err, addition)
+	}
+}
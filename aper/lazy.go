@@ -0,0 +1,77 @@
+package aper
+
+import "github.com/lvdund/asn1go/utils"
+
+// LazyOpenType is an open type whose value, of type T, is only decoded when
+// it is asked for. Decode keeps the octets of the open type; Get decodes them
+// the first time it is called and caches the value. Until then Encode writes
+// the octets back unchanged, unless the writer is canonical. PT must be *T:
+//
+//	var ie LazyOpenType[RANUENGAPID, *RANUENGAPID]
+type LazyOpenType[T any, PT interface {
+	*T
+	IE
+}] struct {
+	raw       []byte
+	value     PT
+	canonical bool
+}
+
+// NewLazyOpenType returns a LazyOpenType holding v.
+func NewLazyOpenType[T any, PT interface {
+	*T
+	IE
+}](v PT) *LazyOpenType[T, PT] {
+	return &LazyOpenType[T, PT]{value: v}
+}
+
+// Raw returns the octets read by Decode, or nil if there were none.
+func (l *LazyOpenType[T, PT]) Raw() []byte {
+	return l.raw
+}
+
+// Get returns the value, decoding it on the first call. The value may be
+// modified in place; it is then encoded in place of the octets received.
+func (l *LazyOpenType[T, PT]) Get() (v PT, err error) {
+	if l.value != nil {
+		return l.value, nil
+	}
+	v = PT(new(T))
+	if l.raw != nil {
+		if err = unmarshal(l.raw, v, l.canonical); err != nil {
+			return nil, utils.WrapError("LazyOpenType", err)
+		}
+	}
+	l.value = v
+	return
+}
+
+// Set replaces the value.
+func (l *LazyOpenType[T, PT]) Set(v PT) {
+	l.value = v
+}
+
+func (l *LazyOpenType[T, PT]) Encode(aw *AperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("LazyOpenType", err)
+	}()
+	if l.value == nil && l.raw != nil && !aw.canonical { //the octets may not be canonical
+		err = aw.WriteOpenType(l.raw)
+		return
+	}
+	var v PT
+	if v, err = l.Get(); err != nil {
+		return
+	}
+	err = aw.WriteOpenTypeValue(v)
+	return
+}
+
+func (l *LazyOpenType[T, PT]) Decode(ar *AperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("LazyOpenType", err)
+	}()
+	l.value, l.canonical = nil, ar.canonical
+	l.raw, err = ar.ReadOpenType()
+	return
+}
@@ -0,0 +1,41 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestLazyOpenType(t *testing.T) {
+	// the sender padded its open type with an extra octet
+	received := encodeTest(t, func(aw *AperWriter) error { return aw.WriteOpenType([]byte{0x80, 0x05, 0xff}) })
+
+	var l LazyOpenType[testPair, *testPair]
+	if err := l.Decode(NewReader(bytes.NewReader(received))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := encodeTest(t, l.Encode); !bytes.Equal(got, received) {
+		t.Errorf("Encode() before Get() = %x, want %x", got, received)
+	}
+
+	v, err := l.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if *v != (testPair{Flag: true, Value: 5}) {
+		t.Errorf("Get() = %+v", *v)
+	}
+	if again, _ := l.Get(); again != v {
+		t.Error("Get() decoded the value again")
+	}
+	v.Value = 6
+	if got, want := hex.EncodeToString(encodeTest(t, l.Encode)), "028006"; got != want {
+		t.Errorf("Encode() after Get() = %s, want %s", got, want)
+	}
+
+	// the zero value holds the zero value of T
+	var zero LazyOpenType[testPair, *testPair]
+	if got, want := hex.EncodeToString(encodeTest(t, zero.Encode)), "020000"; got != want {
+		t.Errorf("Encode() of the zero value = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package aper
+
+import "github.com/lvdund/asn1go/utils"
+
+// WriteIntegerList encodes a SEQUENCE OF INTEGER: size constrains the number
+// of elements and elem every element.
+func (aw *AperWriter) WriteIntegerList(vals []int64, size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) error {
+	return aw.WriteIntegerListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// WriteIntegerListWith encodes a SEQUENCE OF INTEGER with PER-visible size and
+// element constraints.
+func (aw *AperWriter) WriteIntegerListWith(vals []int64, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteIntegerList", err)
+	}()
+	err = aw.writeSequenceOf(len(vals), size, func(i int) error { return aw.WriteIntegerWith(vals[i], elem) })
+	return
+}
+
+// ReadIntegerList decodes a SEQUENCE OF INTEGER written by WriteIntegerList
+// with the same constraints.
+func (ar *AperReader) ReadIntegerList(size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) ([]int64, error) {
+	return ar.ReadIntegerListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// ReadIntegerListWith decodes a SEQUENCE OF INTEGER with PER-visible size and
+// element constraints.
+func (ar *AperReader) ReadIntegerListWith(size, elem *PerConstraint) (vals []int64, err error) {
+	defer func() {
+		err = utils.WrapError("ReadIntegerList", err)
+	}()
+	err = ar.readSequenceOf(size, func() error {
+		v, err := ar.ReadIntegerWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, v)
+		return nil
+	})
+	return
+}
+
+// WriteOctetStringList encodes a SEQUENCE OF OCTET STRING: size constrains the
+// number of elements and elem the size of every element.
+func (aw *AperWriter) WriteOctetStringList(vals [][]byte, size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) error {
+	return aw.WriteOctetStringListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// WriteOctetStringListWith encodes a SEQUENCE OF OCTET STRING with PER-visible
+// size constraints on the list and on its elements.
+func (aw *AperWriter) WriteOctetStringListWith(vals [][]byte, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteOctetStringList", err)
+	}()
+	err = aw.writeSequenceOf(len(vals), size, func(i int) error { return aw.WriteOctetStringWith(vals[i], elem) })
+	return
+}
+
+// ReadOctetStringList decodes a SEQUENCE OF OCTET STRING written by
+// WriteOctetStringList with the same constraints.
+func (ar *AperReader) ReadOctetStringList(size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) ([][]byte, error) {
+	return ar.ReadOctetStringListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// ReadOctetStringListWith decodes a SEQUENCE OF OCTET STRING with PER-visible
+// size constraints on the list and on its elements.
+func (ar *AperReader) ReadOctetStringListWith(size, elem *PerConstraint) (vals [][]byte, err error) {
+	defer func() {
+		err = utils.WrapError("ReadOctetStringList", err)
+	}()
+	err = ar.readSequenceOf(size, func() error {
+		v, err := ar.ReadOctetStringWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, v)
+		return nil
+	})
+	return
+}
+
+// WriteBitStringList encodes a SEQUENCE OF BIT STRING: size constrains the
+// number of elements and elem the size of every element.
+func (aw *AperWriter) WriteBitStringList(vals []BitString, size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) error {
+	return aw.WriteBitStringListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// WriteBitStringListWith encodes a SEQUENCE OF BIT STRING with PER-visible
+// size constraints on the list and on its elements.
+func (aw *AperWriter) WriteBitStringListWith(vals []BitString, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteBitStringList", err)
+	}()
+	err = aw.writeSequenceOf(len(vals), size, func(i int) error {
+		return aw.WriteBitStringWith(vals[i].Bytes, uint(vals[i].NumBits), elem)
+	})
+	return
+}
+
+// ReadBitStringList decodes a SEQUENCE OF BIT STRING written by
+// WriteBitStringList with the same constraints.
+func (ar *AperReader) ReadBitStringList(size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) ([]BitString, error) {
+	return ar.ReadBitStringListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// ReadBitStringListWith decodes a SEQUENCE OF BIT STRING with PER-visible size
+// constraints on the list and on its elements.
+func (ar *AperReader) ReadBitStringListWith(size, elem *PerConstraint) (vals []BitString, err error) {
+	defer func() {
+		err = utils.WrapError("ReadBitStringList", err)
+	}()
+	err = ar.readSequenceOf(size, func() error {
+		content, nbits, err := ar.ReadBitStringWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, BitString{Bytes: content, NumBits: uint64(nbits)})
+		return nil
+	})
+	return
+}
+
+// WriteEnumList encodes a SEQUENCE OF ENUMERATED: size constrains the number
+// of elements and elem the index of every element.
+func (aw *AperWriter) WriteEnumList(vals []uint64, size *Constraint, sizeExt bool, elem Constraint, elemExt bool) error {
+	return aw.WriteEnumListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(&elem, elemExt))
+}
+
+// WriteEnumListWith encodes a SEQUENCE OF ENUMERATED with PER-visible size and
+// element constraints; elem must have both bounds.
+func (aw *AperWriter) WriteEnumListWith(vals []uint64, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteEnumList", err)
+	}()
+	err = aw.writeSequenceOf(len(vals), size, func(i int) error { return aw.WriteEnumerateWith(vals[i], elem) })
+	return
+}
+
+// ReadEnumList decodes a SEQUENCE OF ENUMERATED written by WriteEnumList with
+// the same constraints.
+func (ar *AperReader) ReadEnumList(size *Constraint, sizeExt bool, elem Constraint, elemExt bool) ([]uint64, error) {
+	return ar.ReadEnumListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(&elem, elemExt))
+}
+
+// ReadEnumListWith decodes a SEQUENCE OF ENUMERATED with PER-visible size and
+// element constraints.
+func (ar *AperReader) ReadEnumListWith(size, elem *PerConstraint) (vals []uint64, err error) {
+	defer func() {
+		err = utils.WrapError("ReadEnumList", err)
+	}()
+	err = ar.readSequenceOf(size, func() error {
+		v, err := ar.ReadEnumerateWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, v)
+		return nil
+	})
+	return
+}
@@ -0,0 +1,124 @@
+package aper
+
+import (
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/lvdund/asn1go/utils"
+)
+
+// ObjectIdentifier is an OBJECT IDENTIFIER value given by its arcs, e.g.
+// {1, 2, 840, 113549} for 1.2.840.113549.
+type ObjectIdentifier []uint64
+
+func (oid ObjectIdentifier) String() string {
+	arcs := make([]string, len(oid))
+	for i, arc := range oid {
+		arcs[i] = strconv.FormatUint(arc, 10)
+	}
+	return strings.Join(arcs, ".")
+}
+
+// Equal reports whether oid and o have the same arcs.
+func (oid ObjectIdentifier) Equal(o ObjectIdentifier) bool {
+	if len(oid) != len(o) {
+		return false
+	}
+	for i := range oid {
+		if oid[i] != o[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// contents returns the contents octets of the BER encoding of oid (X.690
+// clause 8.19): the first two arcs are combined into one subidentifier and
+// every subidentifier is written in base 128, high bit set on all but the last
+// octet.
+func (oid ObjectIdentifier) contents() (b []byte, err error) {
+	if len(oid) < 2 || oid[0] > 2 || (oid[0] < 2 && oid[1] >= 40) || oid[1] > math.MaxUint64-80 {
+		err = ErrInvalidObjectIdentifier
+		return
+	}
+	b = appendSubidentifier(b, oid[0]*40+oid[1])
+	for _, arc := range oid[2:] {
+		b = appendSubidentifier(b, arc)
+	}
+	return
+}
+
+func appendSubidentifier(b []byte, v uint64) []byte {
+	n := max((bits.Len64(v)+6)/7, 1)
+	for i := n - 1; i >= 0; i-- {
+		c := byte(v>>(7*i)) & 0x7f
+		if i > 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// parseObjectIdentifier decodes the contents octets of an OBJECT IDENTIFIER.
+func parseObjectIdentifier(b []byte) (oid ObjectIdentifier, err error) {
+	if len(b) == 0 || b[len(b)-1]&0x80 != 0 {
+		err = ErrInvalidObjectIdentifier
+		return
+	}
+	var v uint64
+	start := true
+	for _, c := range b {
+		if start && c == 0x80 { //subidentifiers are encoded in the fewest octets
+			err = ErrInvalidObjectIdentifier
+			return
+		}
+		if v > math.MaxUint64>>7 {
+			err = ErrOverflow
+			return
+		}
+		v = v<<7 | uint64(c&0x7f)
+		if start = c&0x80 == 0; !start {
+			continue
+		}
+		switch {
+		case len(oid) > 0:
+			oid = append(oid, v)
+		case v < 80:
+			oid = append(oid, v/40, v%40)
+		default:
+			oid = append(oid, 2, v-80)
+		}
+		v = 0
+	}
+	return
+}
+
+// WriteObjectIdentifier encodes an OBJECT IDENTIFIER as the contents octets of
+// its BER encoding preceded by an unconstrained length (X.691 clause 24).
+func (aw *AperWriter) WriteObjectIdentifier(oid ObjectIdentifier) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteObjectIdentifier", err)
+	}()
+	var content []byte
+	if content, err = oid.contents(); err != nil {
+		return
+	}
+	err = aw.WriteOctetString(content, nil, false)
+	return
+}
+
+// ReadObjectIdentifier decodes an OBJECT IDENTIFIER.
+func (ar *AperReader) ReadObjectIdentifier() (oid ObjectIdentifier, err error) {
+	defer func() {
+		err = utils.WrapError("ReadObjectIdentifier", err)
+	}()
+	var content []byte
+	if content, err = ar.ReadOctetString(nil, false); err != nil {
+		return
+	}
+	oid, err = parseObjectIdentifier(content)
+	return
+}
@@ -0,0 +1,127 @@
+package aper
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// encodeTest runs encode on a new writer and returns the encoding.
+func encodeTest(t *testing.T, encode func(aw *AperWriter) error) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := encode(aw); err != nil {
+		t.Fatalf("encode error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// A newer release of PersonnelRecord adds a third group and a single
+// addition; the current release only knows the first two groups.
+func TestSequenceDecoder_RawExtensions(t *testing.T) {
+	age := &Constraint{Lb: 1, Ub: 8}
+	small, large := &Constraint{Lb: 1, Ub: 2}, &Constraint{Lb: 1, Ub: 32}
+	wide := &Constraint{Lb: 0, Ub: 65535}
+	tests := []struct {
+		name             string
+		age1, age4, age6 *int64
+		group3, single   *int64
+	}{
+		{"unknown group and addition", int64Ptr(6), int64Ptr(30), nil, int64Ptr(1000), int64Ptr(7)},
+		{"only unknown additions", nil, nil, nil, nil, int64Ptr(7)},
+		{"known groups only", nil, int64Ptr(3), int64Ptr(17), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newer := SequenceEncoder{
+				Extensible: true,
+				Root: []SequenceField{
+					{Encode: func(aw *AperWriter) error { return aw.WriteInteger(5, age, false) }},
+					optionalInteger(tt.age1, age),
+				},
+				Extensions: [][]SequenceField{
+					{optionalInteger(nil, small), optionalInteger(tt.age4, large)},
+					{optionalInteger(nil, small), optionalInteger(tt.age6, large)},
+					{optionalInteger(tt.group3, wide)},
+					{{Present: tt.single != nil, Encode: func(aw *AperWriter) error {
+						return aw.WriteInteger(*tt.single, wide, false)
+					}}},
+				},
+			}
+			received := encodeTest(t, newer.Encode)
+
+			var age0 int64
+			var age1, age2, age4, age5, age6 *int64
+			sd := SequenceDecoder{
+				Extensible: true,
+				Root: []SequenceFieldDecoder{
+					{Decode: func(ar *AperReader) (err error) {
+						age0, err = ar.ReadInteger(age, false)
+						return
+					}},
+					decodeOptionalInteger(&age1, age),
+				},
+				Extensions: [][]SequenceFieldDecoder{
+					{decodeOptionalInteger(&age2, small), decodeOptionalInteger(&age4, large)},
+					{decodeOptionalInteger(&age5, small), decodeOptionalInteger(&age6, large)},
+				},
+			}
+			if err := sd.Decode(NewReader(bytes.NewReader(received))); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+
+			current := SequenceEncoder{
+				Extensible: true,
+				Root: []SequenceField{
+					{Encode: func(aw *AperWriter) error { return aw.WriteInteger(age0, age, false) }},
+					optionalInteger(age1, age),
+				},
+				Extensions: [][]SequenceField{
+					{optionalInteger(age2, small), optionalInteger(age4, large)},
+					{optionalInteger(age5, small), optionalInteger(age6, large)},
+				},
+				Raw: &sd.Raw,
+			}
+			if got := encodeTest(t, current.Encode); !bytes.Equal(got, received) {
+				t.Errorf("re-encoded %x, received %x", got, received)
+			}
+		})
+	}
+}
+
+func TestReadChoiceValue_RawOpenType(t *testing.T) {
+	// the fifth alternative of a newer release, unknown to testChoiceAlternative
+	received := encodeTest(t, func(aw *AperWriter) error {
+		return aw.WriteChoiceValue(5, 1, true, &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 65535}, Value: 300})
+	})
+	choice, raw, err := NewReader(bytes.NewReader(received)).ReadChoiceValue(1, true, testChoiceAlternative)
+	if err != nil {
+		t.Fatalf("ReadChoiceValue() error = %v", err)
+	}
+	got := encodeTest(t, func(aw *AperWriter) error {
+		return aw.WriteChoiceValue(choice, 1, true, RawOpenType(raw))
+	})
+	if !bytes.Equal(got, received) {
+		t.Errorf("re-encoded %x, received %x", got, received)
+	}
+}
+
+func TestReadNamedEnum_Reencode(t *testing.T) {
+	newer := NewEnumerationDescriptor([]int64{0, 5, 10}, true, 3, 4, 8)
+	older := NewEnumerationDescriptor([]int64{0, 5, 10}, true, 3)
+	received := encodeTest(t, func(aw *AperWriter) error { return aw.WriteNamedEnum(8, newer) })
+
+	_, err := NewReader(bytes.NewReader(received)).ReadNamedEnum(older)
+	var unknown *UnknownExtensionError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("ReadNamedEnum() error = %v, want *UnknownExtensionError", err)
+	}
+	got := encodeTest(t, func(aw *AperWriter) error { return aw.WriteNamedEnumIndex(unknown.Index, true, older) })
+	if !bytes.Equal(got, received) {
+		t.Errorf("re-encoded %x, received %x", got, received)
+	}
+}
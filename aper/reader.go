@@ -3,7 +3,6 @@ package aper
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
 	"io"
 	"math/bits"
 
@@ -12,6 +11,7 @@ import (
 
 type AperReader struct {
 	*bitstreamReader
+	canonical bool
 }
 
 func NewReader(r io.Reader) *AperReader {
@@ -20,6 +20,20 @@ func NewReader(r io.Reader) *AperReader {
 	}
 }
 
+// SetCanonical makes the reader accept CANONICAL-PER encodings only: a whole
+// number with more octets than it needs, a component encoded with its
+// DEFAULT value, an unsorted SET OF or a named bit string with trailing 0
+// bits fail with ErrNonCanonical, in the values read as open types or
+// contained encodings too.
+func (ar *AperReader) SetCanonical(canonical bool) {
+	ar.canonical = canonical
+}
+
+// Canonical reports whether the reader only accepts CANONICAL-PER.
+func (ar *AperReader) Canonical() bool {
+	return ar.canonical
+}
+
 func (ar *AperReader) readBytes(nbytes uint) (output []byte, err error) {
 	return ar.ReadBits(nbytes * 8)
 }
@@ -70,6 +84,62 @@ func (ar *AperReader) readConstraintValue(r uint64) (v uint64, err error) {
 	return
 }
 
+// readConstrainedWholeNumber decodes the offset from the lower bound of a
+// value whose range is span+1 (see writeConstrainedWholeNumber)
+func (ar *AperReader) readConstrainedWholeNumber(span uint64) (v uint64, err error) {
+	defer func() {
+		err = utils.WrapError("readConstrainedWholeNumber", err)
+	}()
+
+	if span == 0 {
+		return
+	}
+	if span < POW_16 {
+		v, err = ar.readConstraintValue(span + 1)
+		return
+	}
+	maxLength := octetsOf(span)
+	var length uint64
+	if length, err = ar.readValue(uint(bits.Len64(uint64(maxLength - 1)))); err != nil {
+		return
+	}
+	ar.align()
+	if v, err = ar.readValue(uint(length+1) * 8); err != nil {
+		return
+	}
+	if ar.canonical && octetsOf(v) != uint(length+1) {
+		err = ErrNonCanonical
+	}
+	return
+}
+
+// readUnconstrainedWholeNumber decodes a length determinant followed by a two's
+// complement value
+func (ar *AperReader) readUnconstrainedWholeNumber() (v int64, err error) {
+	defer func() {
+		err = utils.WrapError("readUnconstrainedWholeNumber", err)
+	}()
+
+	var length uint64
+	if length, _, err = ar.readLength(0); err != nil {
+		return
+	}
+	if length == 0 || length > 8 {
+		err = ErrInvalidLength
+		return
+	}
+	var raw uint64
+	if raw, err = ar.readValue(uint(length) * 8); err != nil {
+		return
+	}
+	shift := 64 - uint(length)*8
+	v = int64(raw<<shift) >> shift
+	if ar.canonical && signedOctetsOf(v) != uint(length) {
+		err = ErrNonCanonical
+	}
+	return
+}
+
 func (ar *AperReader) readSemiConstraintWholeNumber(lb uint64) (v uint64, err error) {
 	defer func() {
 		err = utils.WrapError("readSemiConstraintWholeNumber", err)
@@ -84,6 +154,10 @@ func (ar *AperReader) readSemiConstraintWholeNumber(lb uint64) (v uint64, err er
 	if v, err = ar.readValue(uint(length) * 8); err != nil {
 		return
 	}
+	if ar.canonical && octetsOf(v) != uint(length) {
+		err = ErrNonCanonical
+		return
+	}
 	v += lb
 	return
 }
@@ -153,37 +227,66 @@ func (ar *AperReader) readLength(lRange uint64) (value uint64, more bool, err er
 	return
 }
 
+// ReadString is the legacy form of ReadStringWith.
 func (ar *AperReader) ReadString(c *Constraint, e bool, isBitstring bool) (content []byte, nbits uint, err error) {
+	return ar.ReadStringWith(NewPerConstraint(c, e), isBitstring)
+}
+
+// ReadStringWith decodes a BIT STRING (isBitstring) or an OCTET STRING whose
+// size is constrained by pc. nbits is the length of content in bits.
+func (ar *AperReader) ReadStringWith(pc *PerConstraint, isBitstring bool) (content []byte, nbits uint, err error) {
 	defer func() {
 		if isBitstring {
 			err = utils.WrapError("ReadString BitString", err)
+		} else {
+			err = utils.WrapError("ReadString OctetString", err)
 		}
-		err = utils.WrapError("ReadString OctetString", err)
 	}()
-	lRange, lowerBound, err := ar.readExBit(c, e)
+	lb, ub, hasUb, err := pc.SizeBounds()
 	if err != nil {
-		return nil, 0, err
+		return
 	}
-
-	if lRange > 0 && uint64(c.Ub) >= POW_16 { //if upper bound is at least 16 bits then set as semi-constrain
-		lRange = 0
+	if pc.IsExtensible() {
+		var exBit bool
+		if exBit, err = ar.ReadBool(); err != nil {
+			return
+		}
+		if exBit { //outside the extension root the size is semi-constrained
+			lb, hasUb = 0, false
+		}
 	}
 
-	if lRange == 1 { //constrained with fixed length
+	if hasUb && ub < POW_16 {
+		length := lb
+		if lb != ub {
+			if length, err = ar.readConstraintValue(ub - lb + 1); err != nil {
+				return
+			}
+			length += lb
+		}
 		var numBytes uint
 		if isBitstring {
-			nbits = uint(c.Lb)
+			nbits = uint(length)
 			numBytes = (nbits + 7) >> 3
 		} else {
-			numBytes = uint(c.Lb)
+			numBytes = uint(length)
 			nbits = numBytes * 8
 		}
-		if numBytes > 2 { //if more than 2 bytes, need align byte first
+		if lb != ub { //content of a varying length is octet-aligned
+			if nbits > 0 {
+				ar.align()
+			}
+		} else if numBytes > 2 { //if more than 2 bytes, need align byte first
 			ar.align()
 		}
+		if nbits == 0 {
+			content = []byte{}
+			return
+		}
 		content, err = ar.ReadBits(nbits)
 		return
 	}
+
 	var buf bytes.Buffer
 	var tmpBytes []byte
 	partWriter := NewBitStreamWriter(&buf) //a bitstream writer to write parts of content
@@ -191,10 +294,9 @@ func (ar *AperReader) ReadString(c *Constraint, e bool, isBitstring bool) (conte
 	var partLen uint64                     //length of a part to read
 	for more {
 		//read part length first
-		if partLen, more, err = ar.readLength(lRange); err != nil {
+		if partLen, more, err = ar.readLength(0); err != nil {
 			return
 		}
-		partLen += uint64(lowerBound)
 		if partLen == 0 {
 			//last part has zeros length, skip reading
 			break
@@ -204,10 +306,10 @@ func (ar *AperReader) ReadString(c *Constraint, e bool, isBitstring bool) (conte
 		var partLenBits uint64
 		if isBitstring {
 			partLenBits = partLen
-			nbits += uint(partLen)
 		} else {
 			partLenBits = partLen * 8
 		}
+		nbits += uint(partLenBits)
 		if tmpBytes, err = ar.ReadBits(uint(partLenBits)); err != nil {
 			return
 		}
@@ -222,20 +324,29 @@ func (ar *AperReader) ReadString(c *Constraint, e bool, isBitstring bool) (conte
 }
 
 func (ar *AperReader) ReadBitString(c *Constraint, e bool) (content []byte, nbits uint, err error) {
+	return ar.ReadBitStringWith(NewPerConstraint(c, e))
+}
+
+func (ar *AperReader) ReadBitStringWith(pc *PerConstraint) (content []byte, nbits uint, err error) {
 	defer func() {
 		err = utils.WrapError("ReadBitString", err)
 	}()
-	content, nbits, err = ar.ReadString(c, e, true)
+	content, nbits, err = ar.ReadStringWith(pc, true)
 	if err != nil {
 		return
 	}
 	return content, nbits, nil
 }
+
 func (ar *AperReader) ReadOctetString(c *Constraint, e bool) (content []byte, err error) {
+	return ar.ReadOctetStringWith(NewPerConstraint(c, e))
+}
+
+func (ar *AperReader) ReadOctetStringWith(pc *PerConstraint) (content []byte, err error) {
 	defer func() {
 		err = utils.WrapError("ReadOctetString", err)
 	}()
-	content, _, err = ar.ReadString(c, e, false)
+	content, _, err = ar.ReadStringWith(pc, false)
 	if err != nil {
 		return
 	}
@@ -248,87 +359,77 @@ func (ar *AperReader) ReadOpenType() (octets []byte, err error) {
 	return
 }
 
-func (ar *AperReader) ReadInteger(c *Constraint, e bool) (value int64, err error) {
+// ReadOpenTypeValue reads an open type and decodes its octets into value.
+func (ar *AperReader) ReadOpenTypeValue(value AperUnmarshaller) (err error) {
 	defer func() {
-		err = utils.WrapError("ReadInteger", err)
+		err = utils.WrapError("ReadOpenTypeValue", err)
 	}()
+	var octets []byte
+	if octets, err = ar.ReadOpenType(); err != nil {
+		return
+	}
+	err = unmarshal(octets, value, ar.canonical)
+	return
+}
 
-	sRange, _, err := ar.readExBit(c, e)
+func (ar *AperReader) ReadInteger(c *Constraint, e bool) (value int64, err error) {
+	return ar.ReadIntegerWith(NewPerConstraint(c, e))
+}
 
-	if err != nil {
-		return 0, err
-	}
-	var rawLength uint
-	switch {
-	case sRange == 1:
-		value = c.Lb
+// ReadIntegerWith decodes an INTEGER encoded by WriteIntegerWith with the same
+// constraint.
+func (ar *AperReader) ReadIntegerWith(pc *PerConstraint) (value int64, err error) {
+	defer func() {
+		err = utils.WrapError("ReadInteger", err)
+	}()
+	if err = pc.Validate(); err != nil {
 		return
-	case sRange == 0:
-		ar.align()
-		var tmp byte
-		if tmp, err = ar.readByte(); err != nil {
+	}
+	if pc.IsExtensible() {
+		var exBit bool
+		if exBit, err = ar.ReadBool(); err != nil {
 			return
 		}
-		rawLength = uint(tmp)
-
-	case uint64(sRange) <= POW_16:
-		var tmp uint64
-		if tmp, err = ar.readConstraintValue(uint64(sRange)); err != nil {
+		if exBit { //extension values are encoded as unconstrained whole numbers
+			value, err = ar.readUnconstrainedWholeNumber()
 			return
 		}
-		value = int64(tmp) + c.Lb //c is non-nil
-		return
+	}
 
-	default: //sRange > POW_16, c is non-nil
-		unsignedValueRange := uint64(sRange - 1)
-		var byteLen uint
-		for byteLen = 1; byteLen <= 127; byteLen++ {
-			unsignedValueRange >>= 8
-			if unsignedValueRange == 0 {
-				break
-			}
-		}
-		var bitLength, upper uint
-		// 1 ~ 8 bits
-		for bitLength = 1; bitLength <= 8; bitLength++ {
-			upper = 1 << bitLength
-			if upper >= byteLen {
-				break
-			}
-		}
-		var tmp uint64
-		if tmp, err = ar.readValue(uint(bitLength)); err != nil {
+	var tmp uint64
+	switch pc.Category() {
+	case Constrained:
+		if tmp, err = ar.readConstrainedWholeNumber(pc.Span()); err != nil {
 			return
 		}
-		rawLength = uint(tmp) + 1
-		ar.align()
-	}
-
-	var rawValue uint64
-	if rawValue, err = ar.readValue(rawLength * 8); err != nil {
-		return
-	}
-	if sRange == 0 { //unconstraint
-		signedBitMask := uint64(1 << (rawLength*8 - 1))
-		valueMask := signedBitMask - 1
-		if rawValue&signedBitMask > 0 {
-			value = int64((^rawValue)&valueMask+1) * -1
-		} else {
-			value = int64(rawValue)
+		value = int64(tmp + uint64(pc.Lb))
+	case SemiConstrained:
+		if tmp, err = ar.readSemiConstraintWholeNumber(0); err != nil {
+			return
 		}
-	} else { //with constraint
-		value = int64(rawValue) + c.Lb //c is non-nil
+		value = int64(tmp + uint64(pc.Lb))
+	default:
+		value, err = ar.readUnconstrainedWholeNumber()
 	}
 	return
 }
 
 // constrain must have Lb <= Ub
 func (ar *AperReader) ReadEnumerate(c Constraint, e bool) (v uint64, err error) {
+	return ar.ReadEnumerateWith(NewPerConstraint(&c, e))
+}
+
+// ReadEnumerateWith decodes an enumeration index; pc must have both bounds.
+func (ar *AperReader) ReadEnumerateWith(pc *PerConstraint) (v uint64, err error) {
 	defer func() {
 		err = utils.WrapError("ReadEnumerate", err)
 	}()
+	if pc.Category() != Constrained || pc.Lb < 0 || pc.Validate() != nil {
+		err = ErrConstraint
+		return
+	}
 
-	if e { //if extensible is true, read the extention bit
+	if pc.Extensible { //if extensible is true, read the extention bit
 		var exBit bool
 		if exBit, err = ar.ReadBool(); err != nil {
 			return
@@ -338,23 +439,26 @@ func (ar *AperReader) ReadEnumerate(c Constraint, e bool) (v uint64, err error)
 			if tmp, err = ar.readNormallySmallNonNegativeValue(); err != nil {
 				return
 			}
-			v = tmp + uint64(c.Ub) + 1 //adjust value with upper bound
+			v = tmp + uint64(pc.Ub) + 1 //adjust value with upper bound
 			return
 		}
 	}
 	//value is contrained
-	if c.Range() > 1 {
+	if pc.Range() > 1 {
 		var tmp uint64
-		if tmp, err = ar.readConstraintValue(c.Range()); err != nil {
+		if tmp, err = ar.readConstraintValue(pc.Range()); err != nil {
 			return
 		}
-		v = tmp + uint64(c.Lb) //adjust value with lower bound
+		v = tmp + uint64(pc.Lb) //adjust value with lower bound
 	} else {
-		v = uint64(c.Lb) //range is 1, use the bound
+		v = uint64(pc.Lb) //range is 1, use the bound
 	}
 	return
 }
 
+// ReadChoice reads the index (starting from 1) of a CHOICE alternative; see
+// WriteChoice. For an extension alternative the caller must then read the
+// value as an open type.
 func (ar *AperReader) ReadChoice(uBound uint64, e bool) (v uint64, err error) {
 	defer func() {
 		err = utils.WrapError("ReadChoice", err)
@@ -366,7 +470,11 @@ func (ar *AperReader) ReadChoice(uBound uint64, e bool) (v uint64, err error) {
 			return
 		}
 		if exBit {
-			err = fmt.Errorf("Choice extension not supported")
+			var tmp uint64
+			if tmp, err = ar.readNormallySmallNonNegativeValue(); err != nil {
+				return
+			}
+			v = uBound + tmp + 2
 			return
 		}
 	}
@@ -378,6 +486,31 @@ func (ar *AperReader) ReadChoice(uBound uint64, e bool) (v uint64, err error) {
 	return
 }
 
+// ReadChoiceValue reads a CHOICE alternative and its value. alternative
+// returns the value to decode into for an index, or nil if the index is not
+// known. The open-type octets of an unknown extension alternative are
+// returned in raw; an unknown root alternative is an error.
+func (ar *AperReader) ReadChoiceValue(uBound uint64, e bool, alternative func(v uint64) AperUnmarshaller) (v uint64, raw []byte, err error) {
+	if v, err = ar.ReadChoice(uBound, e); err != nil {
+		return
+	}
+	value := alternative(v)
+	if v-1 <= uBound {
+		if value == nil {
+			err = utils.WrapError("ReadChoiceValue", ErrUnknownChoice)
+			return
+		}
+		err = value.Decode(ar)
+		return
+	}
+	if value == nil {
+		raw, err = ar.ReadOpenType()
+		return
+	}
+	err = ar.ReadOpenTypeValue(value)
+	return
+}
+
 // ReadBoolean decodes an ASN.1 BOOLEAN value according to APER rules.
 // A BOOLEAN is decoded from a single bit: 1 for true, 0 for false.
 func (ar *AperReader) ReadBoolean() (value bool, err error) {
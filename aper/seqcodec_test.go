@@ -0,0 +1,175 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// optionalInteger returns the component of an OPTIONAL INTEGER held by v.
+func optionalInteger(v *int64, c *Constraint) SequenceField {
+	return SequenceField{
+		Optional: true,
+		Present:  v != nil,
+		Encode: func(aw *AperWriter) error {
+			return aw.WriteInteger(*v, c, false)
+		},
+	}
+}
+
+// decodeOptionalInteger returns the component decoding an OPTIONAL INTEGER
+// into *v.
+func decodeOptionalInteger(v **int64, c *Constraint) SequenceFieldDecoder {
+	return SequenceFieldDecoder{
+		Optional: true,
+		Decode: func(ar *AperReader) error {
+			value, err := ar.ReadInteger(c, false)
+			*v = &value
+			return err
+		},
+	}
+}
+
+// the PersonnelRecord of ext_test.go written with the sequence helpers
+func TestSequenceEncoder(t *testing.T) {
+	age := &Constraint{Lb: 1, Ub: 8}
+	small, large := &Constraint{Lb: 1, Ub: 2}, &Constraint{Lb: 1, Ub: 32}
+	tests := []struct {
+		name       string
+		age0       int64
+		age1       *int64
+		age2, age4 *int64
+		age5, age6 *int64
+		expected   string
+	}{
+		{"root only", 5, int64Ptr(6), nil, nil, nil, nil, "65"},
+		{"root and both groups", 5, int64Ptr(6), int64Ptr(2), int64Ptr(30), int64Ptr(1), int64Ptr(17), "e5038001fd01d0"},
+		{"second group only", 1, nil, nil, nil, nil, int64Ptr(32), "8014017e"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			se := SequenceEncoder{
+				Extensible: true,
+				Root: []SequenceField{
+					{Encode: func(aw *AperWriter) error { return aw.WriteInteger(tt.age0, age, false) }},
+					optionalInteger(tt.age1, age),
+				},
+				Extensions: [][]SequenceField{
+					{optionalInteger(tt.age2, small), optionalInteger(tt.age4, large)},
+					{optionalInteger(tt.age5, small), optionalInteger(tt.age6, large)},
+				},
+			}
+			var buf bytes.Buffer
+			aw := NewWriter(&buf)
+			if err := se.Encode(aw); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("Encode() = %s, want %s", got, tt.expected)
+			}
+
+			var age0 int64
+			var age1, age2, age4, age5, age6 *int64
+			sd := SequenceDecoder{
+				Extensible: true,
+				Root: []SequenceFieldDecoder{
+					{Decode: func(ar *AperReader) (err error) {
+						age0, err = ar.ReadInteger(age, false)
+						return
+					}},
+					decodeOptionalInteger(&age1, age),
+				},
+				// an older release that only knows the first group
+				Extensions: [][]SequenceFieldDecoder{
+					{decodeOptionalInteger(&age2, small), decodeOptionalInteger(&age4, large)},
+				},
+			}
+			if err := sd.Decode(NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if age0 != tt.age0 || (age1 == nil) != (tt.age1 == nil) || (age2 == nil) != (tt.age2 == nil) || (age4 == nil) != (tt.age4 == nil) {
+				t.Errorf("Decode() = %d, %v, %v, %v", age0, age1, age2, age4)
+			}
+			if age5 != nil || age6 != nil {
+				t.Errorf("Decode() decoded the unknown group")
+			}
+		})
+	}
+
+	// a single addition is a group of one mandatory component
+	se := SequenceEncoder{
+		Extensible: true,
+		Root:       []SequenceField{{Encode: func(aw *AperWriter) error { return aw.WriteBool(true) }}},
+		Extensions: [][]SequenceField{{{Present: true, Encode: func(aw *AperWriter) error {
+			return aw.WriteInteger(7, &Constraint{Lb: 0, Ub: 255}, false)
+		}}}},
+	}
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := se.Encode(aw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// 1 1 0000000 1, open type 01 07
+	if got := hex.EncodeToString(buf.Bytes()); got != "c0400107" {
+		t.Errorf("Encode() with a single addition = %s, want c0400107", got)
+	}
+}
+
+// SEQUENCE { a INTEGER (0..7) DEFAULT 3, b BOOLEAN, ..., [[ c INTEGER (0..7) DEFAULT 1 ]] }
+func TestSequenceEncoder_Default(t *testing.T) {
+	c := &Constraint{Lb: 0, Ub: 7}
+	writeInt := func(aw *AperWriter, v int64) error { return aw.WriteInteger(v, c, false) }
+	readInt := func(ar *AperReader) (int64, error) { return ar.ReadInteger(c, false) }
+	tests := []struct {
+		name     string
+		a, c     int64
+		policy   DefaultPolicy
+		expected string
+	}{
+		{"canonical, defaults", 3, 1, DefaultCanonical, "20"},
+		{"canonical, a set", 5, 1, DefaultCanonical, "6c"},
+		{"basic, defaults", 3, 1, DefaultBasic, "dc040190"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			se := SequenceEncoder{
+				Extensible: true,
+				Root: []SequenceField{
+					DefaultField(tt.a, 3, writeInt),
+					{Encode: func(aw *AperWriter) error { return aw.WriteBool(true) }},
+				},
+				Extensions: [][]SequenceField{{DefaultField(tt.c, 1, writeInt)}},
+				Defaults:   tt.policy,
+			}
+			got := encodeTest(t, se.Encode)
+			if hex.EncodeToString(got) != tt.expected {
+				t.Errorf("Encode() = %x, want %s", got, tt.expected)
+			}
+
+			a, b, cv := int64(-1), false, int64(-1)
+			sd := SequenceDecoder{
+				Extensible: true,
+				Root: []SequenceFieldDecoder{
+					DefaultFieldDecoder(&a, 3, readInt),
+					{Decode: func(ar *AperReader) (err error) {
+						b, err = ar.ReadBool()
+						return
+					}},
+				},
+				Extensions: [][]SequenceFieldDecoder{{DefaultFieldDecoder(&cv, 1, readInt)}},
+			}
+			if err := sd.Decode(NewReader(bytes.NewReader(got))); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if a != tt.a || !b || cv != tt.c {
+				t.Errorf("Decode() = %d, %v, %d, want %d, true, %d", a, b, cv, tt.a, tt.c)
+			}
+		})
+	}
+}
@@ -1,137 +1,157 @@
 package aper
 
 import (
-	"fmt"
+	"iter"
 
 	"github.com/lvdund/asn1go/utils"
 )
 
 func WriteSequenceOf[T AperMarshaller](items []T, aw *AperWriter, c *Constraint, e bool) (err error) {
+	return WriteSequenceOfWith(items, aw, NewPerConstraint(c, e))
+}
+
+// WriteSequenceOfWith encodes a SEQUENCE OF whose size is constrained by pc.
+func WriteSequenceOfWith[T AperMarshaller](items []T, aw *AperWriter, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteSequenceOf", err)
 	}()
+	err = aw.writeSequenceOf(len(items), pc, func(i int) error { return items[i].Encode(aw) })
+	return
+}
 
-	numElems := len(items)
+// writeSequenceOf writes the count of a SEQUENCE OF with n elements whose size
+// is constrained by pc, calling encode for every element in turn.
+func (aw *AperWriter) writeSequenceOf(n int, pc *PerConstraint, encode func(i int) error) (err error) {
+	numElems := uint64(n)
 
-	//determine lower bound and size range (contraintness)
-	var lowerBound, sizeRange uint64 = 0, 0
-	if c != nil {
-		if c.Lb < 0 || uint64(c.Lb) >= POW_16 {
-			err = ErrConstraint
-			return
-		}
-		lowerBound = uint64(c.Lb)
-		sizeRange = c.Range()
-		if sizeRange > 0 && uint64(c.Ub) >= POW_16 { //upper bound too large, set as semi-constraint
-			sizeRange = 0
-		}
-	}
-
-	if uint64(numElems) < lowerBound { //too few items
-		err = ErrUnderflow
+	//determine size bounds (contraintness)
+	lowerBound, upperBound, hasUb, err := pc.SizeBounds()
+	if err != nil {
 		return
 	}
 
-	if e {
-		if sizeRange == 0 { //conflict: no constraint vs extension
+	inRoot := numElems >= lowerBound && (!hasUb || numElems <= upperBound)
+	if !inRoot && !pc.IsExtensible() {
+		if numElems < lowerBound { //too few items
+			err = ErrUnderflow
+		} else {
 			err = ErrInextensible
-			return
-		}
-		//write extension bit if needs
-		if err = aw.WriteBool(int64(numElems) > c.Ub); err != nil {
-			return
 		}
+		return
 	}
-	//NOTE: if sizeRange == 1, no need to write sequence size
-	if sizeRange > 1 {
-		if err = aw.writeConstraintValue(sizeRange, uint64(numElems)-lowerBound); err != nil {
-			return
-		}
-	} else if sizeRange == 0 { //unconstraint
-		if err = aw.align(); err != nil {
-			return
-		}
-		if err = aw.writeValue(uint64(numElems&0xff), 8); err != nil {
+	if pc.IsExtensible() {
+		//write extension bit
+		if err = aw.WriteBool(!inRoot); err != nil {
 			return
 		}
 	}
-	//finally, write all itemst
-	for _, item := range items {
-		if err = item.Encode(aw); err != nil {
-			return
-		}
+	if !inRoot { //outside the extension root the count is semi-constrained
+		lowerBound, hasUb = 0, false
 	}
 
-	// with case up_bound = low_bound
-	err = aw.flush()
-
+	//NOTE: if size range is 1, no need to write sequence size
+	constrained := hasUb && upperBound < POW_16
+	if constrained {
+		if sizeRange := upperBound - lowerBound + 1; sizeRange > 1 {
+			if err = aw.writeConstraintValue(sizeRange, numElems-lowerBound); err != nil {
+				return
+			}
+		}
+	}
+	//otherwise the count is a length determinant, in 16K fragments for long lists
+	for start, last := uint64(0), false; !last; {
+		part := numElems - start
+		last = true
+		if !constrained {
+			part, last = fragmentLength(part)
+			if err = aw.writeLength(0, part); err != nil {
+				return
+			}
+		}
+		for i := start; i < start+part; i++ {
+			if err = encode(int(i)); err != nil {
+				return
+			}
+		}
+		start += part
+	}
 	return
 }
 
 func ReadSequenceOf[T any](decoder func(ar *AperReader) (*T, error), ar *AperReader, c *Constraint, e bool) (items []T, err error) {
+	return ReadSequenceOfWith(decoder, ar, NewPerConstraint(c, e))
+}
+
+// ReadSequenceOfWith decodes a SEQUENCE OF whose size is constrained by pc.
+func ReadSequenceOfWith[T any](decoder func(ar *AperReader) (*T, error), ar *AperReader, pc *PerConstraint) (items []T, err error) {
 	//NOTE: decoder is a function that read from the input stream (*AperReader) to decode
 	//a specific aper data structure
+	err = ar.readSequenceOf(pc, func() error {
+		item, err := decoder(ar)
+		if err != nil {
+			return err
+		}
+		items = append(items, *item)
+		return nil
+	})
+	return
+}
 
-	//1. determine lower bound and size range (contraintness)
-	var lowerBound, sizeRange uint64 = 0, 0
-	if c != nil {
-		if c.Lb < 0 || uint64(c.Lb) >= POW_16 {
-			err = ErrConstraint
-			return
-		}
-		lowerBound = uint64(c.Lb)
-		sizeRange = c.Range()
-		if sizeRange > 0 && uint64(c.Ub) >= POW_16 { //upper bound too large, set as semi-constraint
-			sizeRange = 0
-		}
+// readSequenceOf reads the count of a SEQUENCE OF whose size is constrained by
+// pc, calling decode for every element in turn.
+func (ar *AperReader) readSequenceOf(pc *PerConstraint, decode func() error) (err error) {
+	//1. determine size bounds (contraintness)
+	lowerBound, upperBound, hasUb, err := pc.SizeBounds()
+	if err != nil {
+		return
 	}
 
 	//2. read extension bit if needs
-	var exBit bool
-	if e {
-		if sizeRange == 0 { //conflict: no constraint vs extension
-			err = ErrInextensible
-			return
-		}
-
+	if pc.IsExtensible() {
+		var exBit bool
 		if exBit, err = ar.ReadBool(); err != nil {
 			return
 		}
+		if exBit { //outside the extension root the count is semi-constrained
+			lowerBound, hasUb = 0, false
+		}
 	}
 
-	//3. read num elements
+	//3. read num elements, in fragments if the count is a length determinant
 	var numElems uint64
-	if sizeRange == 1 {
+	constrained := hasUb && upperBound < POW_16
+	if constrained {
 		numElems = lowerBound
-	} else if sizeRange > 1 {
-		if numElems, err = ar.readConstraintValue(sizeRange); err != nil {
-			return
-		}
-		numElems += lowerBound
-		if exBit && numElems <= uint64(c.Ub) { //check for consitency of extension bit
-			err = fmt.Errorf("Inconsistent extension bit")
-			return
-		}
-	} else { //no constraint
-		ar.align()
-		if numElems, err = ar.readValue(8); err != nil {
-			return
+		if sizeRange := upperBound - lowerBound + 1; sizeRange > 1 {
+			if numElems, err = ar.readConstraintValue(sizeRange); err != nil {
+				return
+			}
+			numElems += lowerBound
 		}
 	}
-	// fmt.Printf("number of elem= %d\n", numElems)
-	//4. fianly read every elements
-	items = make([]T, numElems)
-	var tmpItem *T
-	for i := 0; i < int(numElems); i++ {
-		//fmt.Println("SequenceOf", i)
-		if tmpItem, err = decoder(ar); err != nil {
-			fmt.Println("\terr", err)
-			return
+	//4. read every element, holding a length-determinant count to the root bounds
+	for more, total := true, uint64(0); more; {
+		count := numElems
+		more = false
+		if !constrained {
+			if count, more, err = ar.readLength(0); err != nil {
+				return
+			}
+			if total += count; hasUb && total > upperBound {
+				err = ErrInextensible
+				return
+			}
+			if !more && total < lowerBound {
+				err = ErrUnderflow
+				return
+			}
+		}
+		for i := uint64(0); i < count; i++ {
+			if err = decode(); err != nil {
+				return
+			}
 		}
-		//fmt.Printf("----------: %v", *tmpItem)
-		items[i] = *tmpItem
 	}
-	//fmt.Printf(" -> %p\n", tmpItem)
 	return
 }
 
@@ -147,20 +167,67 @@ func ReadSequenceOfEx[T AperUnmarshaller](fn func() T, ar *AperReader, c *Constr
 	return
 }
 
+func ReadSequenceOfExWith[T AperUnmarshaller](fn func() T, ar *AperReader, pc *PerConstraint) (items []T, err error) {
+	decoder := func(ar *AperReader) (*T, error) {
+		item := fn()
+		if err := item.Decode(ar); err != nil {
+			return nil, err
+		}
+		return &item, nil
+	}
+	items, err = ReadSequenceOfWith[T](decoder, ar, pc)
+	return
+}
+
+// ReadSequenceOfSeq decodes a SEQUENCE OF one element per iteration, so that
+// a long list need not be held in memory. A decoding error is yielded once
+// and ends the iteration. Breaking out of the loop stops decoding there,
+// leaving ar inside the SEQUENCE OF with no way to skip the remaining
+// elements, so it must not be read further. The sequence reads from ar and
+// can be ranged over once.
+func ReadSequenceOfSeq[T any](decoder func(ar *AperReader) (*T, error), ar *AperReader, c *Constraint, e bool) iter.Seq2[T, error] {
+	return ReadSequenceOfSeqWith(decoder, ar, NewPerConstraint(c, e))
+}
+
+// ReadSequenceOfSeqWith decodes a SEQUENCE OF whose size is constrained by pc
+// one element per iteration.
+func ReadSequenceOfSeqWith[T any](decoder func(ar *AperReader) (*T, error), ar *AperReader, pc *PerConstraint) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		stopped := false
+		err := ar.readSequenceOf(pc, func() error {
+			item, err := decoder(ar)
+			if err != nil {
+				return err
+			}
+			if !yield(*item, nil) {
+				stopped = true
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !stopped {
+			var zero T
+			yield(zero, utils.WrapError("ReadSequenceOf", err))
+		}
+	}
+}
+
 type ListContainer[T AperMarshaller] struct {
 	list []T
-	e    bool
-	c    *Constraint
+	pc   *PerConstraint
 }
 
 func NewListContainer[T AperMarshaller](list []T, c *Constraint, e bool) ListContainer[T] {
+	return NewListContainerWith(list, NewPerConstraint(c, e))
+}
+
+func NewListContainerWith[T AperMarshaller](list []T, pc *PerConstraint) ListContainer[T] {
 	return ListContainer[T]{
 		list: list,
-		e:    e,
-		c:    c,
+		pc:   pc,
 	}
 }
 func (l ListContainer[T]) Encode(aw *AperWriter) (err error) {
-	err = WriteSequenceOf[T](l.list, aw, l.c, l.e)
+	err = WriteSequenceOfWith[T](l.list, aw, l.pc)
 	return
 }
@@ -0,0 +1,215 @@
+package aper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteSequenceOf_LengthDeterminant(t *testing.T) {
+	tests := []struct {
+		count  int
+		prefix map[int]string // expected octets at given offsets
+	}{
+		{255, map[int]string{0: "80ff"}},
+		{256, map[int]string{0: "8100"}},
+		{16383, map[int]string{0: "bfff"}},
+		{16384, map[int]string{0: "c1", 1 + 16384: "00"}},
+		{70000, map[int]string{0: "c4", 1 + 65536: "9170"}},
+	}
+	for _, tt := range tests {
+		items := make([]*testInteger, tt.count)
+		for i := range items {
+			items[i] = &testInteger{Value: int64(i % 256)}
+		}
+		var buf bytes.Buffer
+		aw := NewWriter(&buf)
+		if err := WriteSequenceOf(items, aw, nil, false); err != nil {
+			t.Fatalf("WriteSequenceOf(%d) error = %v", tt.count, err)
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		encoded := buf.Bytes()
+		for offset, want := range tt.prefix {
+			end := offset + len(want)/2
+			if end > len(encoded) {
+				t.Fatalf("WriteSequenceOf(%d) encoded only %d octets", tt.count, len(encoded))
+			}
+			if got := hex.EncodeToString(encoded[offset:end]); got != want {
+				t.Errorf("WriteSequenceOf(%d) octets at %d = %s, want %s", tt.count, offset, got, want)
+			}
+		}
+
+		got, err := ReadSequenceOfEx(func() *testInteger { return new(testInteger) }, NewReader(bytes.NewReader(encoded)), nil, false)
+		if err != nil {
+			t.Fatalf("ReadSequenceOfEx(%d) error = %v", tt.count, err)
+		}
+		if len(got) != tt.count {
+			t.Fatalf("ReadSequenceOfEx() returned %d items, want %d", len(got), tt.count)
+		}
+		for i := range got {
+			if got[i].Value != items[i].Value {
+				t.Fatalf("item %d = %d, want %d", i, got[i].Value, items[i].Value)
+			}
+		}
+	}
+}
+
+// a nested SEQUENCE OF must not pad the rest of the PDU
+func TestWriteSequenceOf_Nested(t *testing.T) {
+	bit := &Constraint{Lb: 1, Ub: 1}
+	items := []*ConstrainedBitString{
+		{C: bit, Value: BitString{Bytes: []byte{0x80}, NumBits: 1}},
+		{C: bit, Value: BitString{Bytes: []byte{0x80}, NumBits: 1}},
+	}
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := WriteSequenceOf(items, aw, &Constraint{Lb: 1, Ub: 8}, false); err != nil {
+		t.Fatalf("WriteSequenceOf() error = %v", err)
+	}
+	if err := aw.WriteBool(true); err != nil {
+		t.Fatalf("WriteBool() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// count 001, bits 1 1, then the boolean 1
+	if got := hex.EncodeToString(buf.Bytes()); got != "3c" {
+		t.Errorf("encoding = %s, want 3c", got)
+	}
+}
+
+// SEQUENCE (SIZE(1..4, ...)) OF INTEGER (0..255)
+func TestWriteSequenceOf_ExtensibleSize(t *testing.T) {
+	size := &Constraint{Lb: 1, Ub: 4}
+	tests := []struct {
+		name     string
+		count    int
+		expected string
+	}{
+		{"in root", 3, "40010203"},
+		{"above root", 6, "8006010203040506"},
+		{"below root", 0, "8000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]*testInteger, tt.count)
+			for i := range items {
+				items[i] = &testInteger{Value: int64(i + 1)}
+			}
+			var buf bytes.Buffer
+			aw := NewWriter(&buf)
+			if err := WriteSequenceOf(items, aw, size, true); err != nil {
+				t.Fatalf("WriteSequenceOf() error = %v", err)
+			}
+			if err := aw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteSequenceOf() = %s, want %s", got, tt.expected)
+			}
+
+			got, err := ReadSequenceOfEx(func() *testInteger { return new(testInteger) }, NewReader(bytes.NewReader(buf.Bytes())), size, true)
+			if err != nil {
+				t.Fatalf("ReadSequenceOfEx() error = %v", err)
+			}
+			if len(got) != tt.count {
+				t.Errorf("ReadSequenceOfEx() returned %d items, want %d", len(got), tt.count)
+			}
+		})
+	}
+
+	if err := WriteSequenceOf(make([]*testInteger, 5), NewWriter(new(bytes.Buffer)), size, false); err == nil {
+		t.Error("expected an error for a count above a non-extensible SIZE")
+	}
+}
+
+// a count written as a length determinant is still held to the root bounds
+func TestReadSequenceOf_LengthDeterminantBounds(t *testing.T) {
+	tests := []struct {
+		name  string
+		pc    *PerConstraint
+		input string
+		err   error
+	}{
+		{"SIZE(2..MAX) count 1", AtLeast(2), "0105", ErrUnderflow},
+		{"SIZE(2..MAX) count 2", AtLeast(2), "020506", nil},
+		{"SIZE(2..70000,...) root count 1", Bounded(2, 70000).Extend(), "000105", ErrUnderflow},
+		{"SIZE(2..70000,...) extended count 1", Bounded(2, 70000).Extend(), "800105", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.input)
+			_, err := ReadSequenceOfWith(decodeTestInteger, NewReader(bytes.NewReader(b)), tt.pc)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ReadSequenceOfWith() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func decodeTestInteger(ar *AperReader) (*testInteger, error) {
+	ti := new(testInteger)
+	return ti, ti.Decode(ar)
+}
+
+func TestReadSequenceOfSeq(t *testing.T) {
+	size := &Constraint{Lb: 1, Ub: 8}
+	items := []*testInteger{{1}, {2}, {3}, {4}, {5}}
+	encoded := encodeTest(t, func(aw *AperWriter) error {
+		if err := WriteSequenceOf(items, aw, size, false); err != nil {
+			return err
+		}
+		return aw.WriteBool(true)
+	})
+
+	var got []int64
+	for v, err := range ReadSequenceOfSeq(decodeTestInteger, NewReader(bytes.NewReader(encoded)), size, false) {
+		if err != nil {
+			t.Fatalf("ReadSequenceOfSeq() error = %v", err)
+		}
+		got = append(got, v.Value)
+	}
+	if !reflect.DeepEqual(got, []int64{1, 2, 3, 4, 5}) {
+		t.Errorf("ReadSequenceOfSeq() = %v", got)
+	}
+
+	// breaking early decodes no further element
+	decoded := 0
+	counting := func(ar *AperReader) (*testInteger, error) {
+		decoded++
+		return decodeTestInteger(ar)
+	}
+	got = got[:0]
+	for v, err := range ReadSequenceOfSeq(counting, NewReader(bytes.NewReader(encoded)), size, false) {
+		if err != nil {
+			t.Fatalf("ReadSequenceOfSeq() error = %v", err)
+		}
+		if got = append(got, v.Value); len(got) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, []int64{1, 2}) || decoded != 2 {
+		t.Errorf("ReadSequenceOfSeq() with break = %v after %d elements", got, decoded)
+	}
+
+	// a truncated list yields its error once, after the complete elements
+	var errs []error
+	got = got[:0]
+	for v, err := range ReadSequenceOfSeq(decodeTestInteger, NewReaderBits(encoded, 20), size, false) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, v.Value)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrIncomplete) {
+		t.Errorf("ReadSequenceOfSeq() of a truncated list errors = %v", errs)
+	}
+	if !reflect.DeepEqual(got, []int64{1, 2}[:1]) {
+		t.Errorf("ReadSequenceOfSeq() of a truncated list = %v", got)
+	}
+}
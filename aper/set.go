@@ -0,0 +1,178 @@
+package aper
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/lvdund/asn1go/internal/per"
+	"github.com/lvdund/asn1go/utils"
+)
+
+// WriteSetOf encodes a SET OF. If aw is canonical the elements are written in
+// ascending order of their encodings, compared as octet strings padded with 0
+// bits (X.691 clause 22); otherwise they are written as given. A SET OF is
+// decoded with ReadSetOf.
+func WriteSetOf[T AperMarshaller](items []T, aw *AperWriter, c *Constraint, e bool) (err error) {
+	return WriteSetOfWith(items, aw, NewPerConstraint(c, e))
+}
+
+// WriteSetOfWith encodes a SET OF whose size is constrained by pc.
+func WriteSetOfWith[T AperMarshaller](items []T, aw *AperWriter, pc *PerConstraint) (err error) {
+	if !aw.canonical {
+		return WriteSequenceOfWith(items, aw, pc)
+	}
+	defer func() {
+		err = utils.WrapError("WriteSetOf", err)
+	}()
+	keys := make([][]byte, len(items))
+	for i, item := range items {
+		if keys[i], err = setOfKey(item); err != nil {
+			return
+		}
+	}
+	sorted := make([]T, len(items))
+	for i, k := range per.SetOfOrder(keys) {
+		sorted[i] = items[k]
+	}
+	err = WriteSequenceOfWith(sorted, aw, pc)
+	return
+}
+
+// setOfKey returns the canonical encoding of a SET OF element padded to a
+// whole number of octets, the key the elements are sorted by.
+func setOfKey(item AperMarshaller) ([]byte, error) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.canonical = true
+	if err := item.Encode(w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadSetOf decodes a SET OF written by WriteSetOf with the same constraint.
+// A canonical reader rejects elements out of the canonical order.
+func ReadSetOf[T any, PT interface {
+	*T
+	AperMarshaller
+}](decoder func(ar *AperReader) (*T, error), ar *AperReader, c *Constraint, e bool) ([]T, error) {
+	return ReadSetOfWith[T, PT](decoder, ar, NewPerConstraint(c, e))
+}
+
+// ReadSetOfWith decodes a SET OF whose size is constrained by pc.
+func ReadSetOfWith[T any, PT interface {
+	*T
+	AperMarshaller
+}](decoder func(ar *AperReader) (*T, error), ar *AperReader, pc *PerConstraint) (items []T, err error) {
+	if items, err = ReadSequenceOfWith(decoder, ar, pc); err != nil || !ar.canonical {
+		return
+	}
+	defer func() {
+		err = utils.WrapError("ReadSetOf", err)
+	}()
+	var prev []byte
+	for i := range items {
+		var key []byte
+		if key, err = setOfKey(PT(&items[i])); err != nil {
+			return nil, err
+		}
+		if i > 0 && per.CompareSetOfKeys(prev, key) > 0 {
+			return nil, ErrNonCanonical
+		}
+		prev = key
+	}
+	return
+}
+
+// TagClass is the class of an ASN.1 tag.
+type TagClass uint8
+
+const (
+	TagUniversal TagClass = iota
+	TagApplication
+	TagContextSpecific
+	TagPrivate
+)
+
+// Tag is the outermost tag of a SET component; for an untagged CHOICE it is
+// the smallest tag of its alternatives.
+type Tag struct {
+	Class  TagClass
+	Number uint64
+}
+
+// less orders tags canonically: by class, universal first, then by number.
+func (t Tag) less(o Tag) bool {
+	if t.Class != o.Class {
+		return t.Class < o.Class
+	}
+	return t.Number < o.Number
+}
+
+// SetField is a SET root component with its tag.
+type SetField struct {
+	Tag Tag
+	SequenceField
+}
+
+// SetEncoder writes a SET: PER encodes it as a SEQUENCE whose root components
+// are sorted by tag (X.691 clause 21), whatever the order they are declared
+// in. Extension additions keep their definition order.
+type SetEncoder struct {
+	Extensible bool
+	Root       []SetField
+	Extensions [][]SequenceField
+	Raw        *RawExtensions
+	Defaults   DefaultPolicy
+}
+
+// Encode writes the SET.
+func (se *SetEncoder) Encode(aw *AperWriter) error {
+	root := append([]SetField(nil), se.Root...)
+	sort.SliceStable(root, func(i, j int) bool { return root[i].Tag.less(root[j].Tag) })
+	seq := SequenceEncoder{
+		Extensible: se.Extensible,
+		Root:       make([]SequenceField, len(root)),
+		Extensions: se.Extensions,
+		Raw:        se.Raw,
+		Defaults:   se.Defaults,
+	}
+	for i := range root {
+		seq.Root[i] = root[i].SequenceField
+	}
+	return seq.Encode(aw)
+}
+
+// SetFieldDecoder is a SET root component to decode, with its tag.
+type SetFieldDecoder struct {
+	Tag Tag
+	SequenceFieldDecoder
+}
+
+// SetDecoder reads a SET written by a SetEncoder with the same declarations.
+type SetDecoder struct {
+	Extensible bool
+	Root       []SetFieldDecoder
+	Extensions [][]SequenceFieldDecoder
+	Raw        RawExtensions
+}
+
+// Decode reads the SET.
+func (sd *SetDecoder) Decode(ar *AperReader) (err error) {
+	root := append([]SetFieldDecoder(nil), sd.Root...)
+	sort.SliceStable(root, func(i, j int) bool { return root[i].Tag.less(root[j].Tag) })
+	seq := SequenceDecoder{
+		Extensible: sd.Extensible,
+		Root:       make([]SequenceFieldDecoder, len(root)),
+		Extensions: sd.Extensions,
+	}
+	for i := range root {
+		seq.Root[i] = root[i].SequenceFieldDecoder
+	}
+	err = seq.Decode(ar)
+	sd.Raw = seq.Raw
+	return
+}
@@ -0,0 +1,76 @@
+package aper
+
+// ConstrainedInteger, ConstrainedEnumerated, ConstrainedOctetString and
+// ConstrainedBitString are the built-in types bundled with their constraint so
+// that they implement IE and can be used as list elements, e.g.
+// SEQUENCE (SIZE(1..8)) OF OCTET STRING (SIZE(4)):
+//
+//	items := []*ConstrainedOctetString{{C: &Constraint{Lb: 4, Ub: 4}, Value: v}}
+//	WriteSequenceOf(items, aw, &Constraint{Lb: 1, Ub: 8}, false)
+//	ReadSequenceOfEx(func() *ConstrainedOctetString {
+//		return &ConstrainedOctetString{C: &Constraint{Lb: 4, Ub: 4}}
+//	}, ar, &Constraint{Lb: 1, Ub: 8}, false)
+//
+// A nil C means no constraint, except for ConstrainedEnumerated which needs
+// one; Ext marks the constraint as extensible.
+
+type ConstrainedInteger struct {
+	C     *Constraint
+	Ext   bool
+	Value int64
+}
+
+func (v *ConstrainedInteger) Encode(aw *AperWriter) error {
+	return aw.WriteInteger(v.Value, v.C, v.Ext)
+}
+
+func (v *ConstrainedInteger) Decode(ar *AperReader) (err error) {
+	v.Value, err = ar.ReadInteger(v.C, v.Ext)
+	return
+}
+
+// ConstrainedEnumerated holds an enumeration index; C must bound the root
+// indexes.
+type ConstrainedEnumerated struct {
+	C     *Constraint
+	Ext   bool
+	Value uint64
+}
+
+func (v *ConstrainedEnumerated) Encode(aw *AperWriter) error {
+	return aw.WriteEnumerateWith(v.Value, NewPerConstraint(v.C, v.Ext))
+}
+
+func (v *ConstrainedEnumerated) Decode(ar *AperReader) (err error) {
+	v.Value, err = ar.ReadEnumerateWith(NewPerConstraint(v.C, v.Ext))
+	return
+}
+
+type ConstrainedOctetString struct {
+	C     *Constraint
+	Ext   bool
+	Value OctetString
+}
+
+func (v *ConstrainedOctetString) Encode(aw *AperWriter) error {
+	return aw.WriteOctetString(v.Value, v.C, v.Ext)
+}
+
+func (v *ConstrainedOctetString) Decode(ar *AperReader) (err error) {
+	v.Value, err = ar.ReadOctetString(v.C, v.Ext)
+	return
+}
+
+type ConstrainedBitString struct {
+	C     *Constraint
+	Ext   bool
+	Value BitString
+}
+
+func (v *ConstrainedBitString) Encode(aw *AperWriter) error {
+	return v.Value.Encode(aw, NewPerConstraint(v.C, v.Ext))
+}
+
+func (v *ConstrainedBitString) Decode(ar *AperReader) error {
+	return v.Value.Decode(ar, NewPerConstraint(v.C, v.Ext))
+}
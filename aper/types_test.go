@@ -0,0 +1,77 @@
+package aper
+
+import (
+	"bytes"
+	"testing"
+)
+
+// roundTripList encodes items as a SEQUENCE (SIZE(1..8)) OF and decodes them
+// back with fn as the element factory.
+func roundTripList[T IE](t *testing.T, items []T, fn func() T) []T {
+	t.Helper()
+	size := &Constraint{Lb: 1, Ub: 8}
+	var buf bytes.Buffer
+	aw := NewWriter(&buf)
+	if err := WriteSequenceOf(items, aw, size, false); err != nil {
+		t.Fatalf("WriteSequenceOf() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	got, err := ReadSequenceOfEx(fn, NewReader(bytes.NewReader(buf.Bytes())), size, false)
+	if err != nil {
+		t.Fatalf("ReadSequenceOfEx() error = %v", err)
+	}
+	if len(got) != len(items) {
+		t.Fatalf("ReadSequenceOfEx() returned %d items, want %d", len(got), len(items))
+	}
+	return got
+}
+
+func TestBuiltinTypes_SequenceOf(t *testing.T) {
+	t.Run("ConstrainedOctetString", func(t *testing.T) {
+		c := &Constraint{Lb: 4, Ub: 4}
+		items := []*ConstrainedOctetString{{C: c, Value: []byte{1, 2, 3, 4}}, {C: c, Value: []byte{5, 6, 7, 8}}}
+		got := roundTripList(t, items, func() *ConstrainedOctetString { return &ConstrainedOctetString{C: c} })
+		for i := range got {
+			if !bytes.Equal(got[i].Value, items[i].Value) {
+				t.Errorf("item %d = %x, want %x", i, got[i].Value, items[i].Value)
+			}
+		}
+	})
+
+	t.Run("ConstrainedInteger", func(t *testing.T) {
+		c := &Constraint{Lb: 0, Ub: 65535}
+		items := []*ConstrainedInteger{{C: c, Ext: true, Value: 70000}, {C: c, Ext: true, Value: 7}}
+		got := roundTripList(t, items, func() *ConstrainedInteger { return &ConstrainedInteger{C: c, Ext: true} })
+		for i := range got {
+			if got[i].Value != items[i].Value {
+				t.Errorf("item %d = %d, want %d", i, got[i].Value, items[i].Value)
+			}
+		}
+	})
+
+	t.Run("ConstrainedEnumerated", func(t *testing.T) {
+		c := &Constraint{Lb: 0, Ub: 2}
+		items := []*ConstrainedEnumerated{{C: c, Ext: true, Value: 2}, {C: c, Ext: true, Value: 4}}
+		got := roundTripList(t, items, func() *ConstrainedEnumerated { return &ConstrainedEnumerated{C: c, Ext: true} })
+		for i := range got {
+			if got[i].Value != items[i].Value {
+				t.Errorf("item %d = %d, want %d", i, got[i].Value, items[i].Value)
+			}
+		}
+	})
+
+	t.Run("ConstrainedBitString", func(t *testing.T) {
+		c := &Constraint{Lb: 3, Ub: 3}
+		var bits BitString
+		bits.FromUint64(0x5, 3)
+		items := []*ConstrainedBitString{{C: c, Value: bits}, {C: c, Value: bits}}
+		got := roundTripList(t, items, func() *ConstrainedBitString { return &ConstrainedBitString{C: c} })
+		for i := range got {
+			if got[i].Value.String() != "'101'B" {
+				t.Errorf("item %d = %s, want '101'B", i, got[i].Value)
+			}
+		}
+	})
+}
@@ -3,6 +3,7 @@ package aper
 import (
 	"fmt"
 	"io"
+	"math/bits"
 )
 
 // shift byte array by a number of bits (positive for left, negative for right)
@@ -83,6 +84,24 @@ func GetBitString(srcBytes []byte, bitsOffset uint, numBits uint) (dstBytes []by
 	return
 }
 
+// octetsOf returns the minimum number of octets (at least one) holding the
+// non-negative binary integer v
+func octetsOf(v uint64) uint {
+	if v == 0 {
+		return 1
+	}
+	return uint(bits.Len64(v)+7) >> 3
+}
+
+// signedOctetsOf returns the minimum number of octets holding v as a two's
+// complement binary integer
+func signedOctetsOf(v int64) uint {
+	if v < 0 {
+		v = ^v
+	}
+	return uint(bits.Len64(uint64(v))+1+7) >> 3
+}
+
 func GetReader(r AperReader) []byte {
 	t := r.bitstreamReader.r
 	data, _ := io.ReadAll(t)
@@ -96,3 +115,17 @@ func GetWriter(w AperWriter) io.Writer {
 func FlushWrite(w *AperWriter) error {
 	return w.flush()
 }
+
+// fragmentLength returns the size of the next fragment of a length
+// determinant when n items remain (X.691 clause 11.9.3.8): blocks of 64K,
+// 48K, 32K or 16K while n is at least 16K, then the remainder, possibly zero,
+// which ends the encoding.
+func fragmentLength(n uint64) (part uint64, last bool) {
+	if n >= POW_16 {
+		return POW_16, false
+	}
+	if n >= POW_14 {
+		return n &^ (POW_14 - 1), false
+	}
+	return n, true
+}
@@ -12,6 +12,7 @@ import (
 
 type AperWriter struct {
 	*bitstreamWriter
+	canonical bool
 }
 
 func NewWriter(w io.Writer) *AperWriter {
@@ -20,6 +21,21 @@ func NewWriter(w io.Writer) *AperWriter {
 	}
 }
 
+// SetCanonical switches the writer to CANONICAL-PER (X.691 clause 6.3):
+// components equal to their DEFAULT value are left out and the elements of a
+// SET OF are sorted, in the values written as open types or contained
+// encodings too. The other encodings of the writer are canonical in any mode:
+// whole numbers take their minimal number of octets and named bit strings
+// are trimmed.
+func (aw *AperWriter) SetCanonical(canonical bool) {
+	aw.canonical = canonical
+}
+
+// Canonical reports whether the writer encodes with CANONICAL-PER.
+func (aw *AperWriter) Canonical() bool {
+	return aw.canonical
+}
+
 func (aw *AperWriter) Close() error {
 	return aw.flush()
 }
@@ -54,7 +70,7 @@ func (aw *AperWriter) writeSemiConstraintWholeNumber(v uint64, lb uint64) (err e
 		return
 	}
 	v -= lb
-	length := (bits.Len64(v) + 7) >> 3
+	length := octetsOf(v)
 	if err = aw.align(); err != nil {
 		return
 	}
@@ -139,16 +155,80 @@ func (aw *AperWriter) writeConstraintValue(r uint64, v uint64) (err error) {
 	return
 }
 
+// writeConstrainedWholeNumber encodes the offset v-lb of a value whose range
+// is span+1. Ranges above 64K use the indefinite length case: the number of
+// value octets as a bit-field, then the octet-aligned value.
+func (aw *AperWriter) writeConstrainedWholeNumber(v uint64, span uint64) (err error) {
+	defer func() {
+		err = utils.WrapError("writeConstrainedWholeNumber", err)
+	}()
+
+	if span == 0 { //single value, nothing to write
+		return
+	}
+	if span < POW_16 {
+		err = aw.writeConstraintValue(span+1, v)
+		return
+	}
+	length := octetsOf(v)
+	maxLength := octetsOf(span)
+	if err = aw.writeValue(uint64(length-1), uint(bits.Len64(uint64(maxLength-1)))); err != nil {
+		return
+	}
+	if err = aw.align(); err != nil {
+		return
+	}
+	err = aw.writeValue(v, length*8)
+	return
+}
+
+// writeUnconstrainedWholeNumber encodes v as a length determinant followed by
+// the minimal two's-complement octets.
+func (aw *AperWriter) writeUnconstrainedWholeNumber(v int64) (err error) {
+	defer func() {
+		err = utils.WrapError("writeUnconstrainedWholeNumber", err)
+	}()
+
+	length := signedOctetsOf(v)
+	if err = aw.writeLength(0, uint64(length)); err != nil {
+		return
+	}
+	err = aw.writeValue(uint64(v), length*8)
+	return
+}
+
+// WriteString is the legacy form of WriteStringWith.
 func (aw *AperWriter) WriteString(content []byte, len uint64, c *Constraint, e bool, isBitstring bool) (err error) {
-	lowerBound, lRange, _ := aw.writeExtBit(len, e, c)
-	if lRange > 0 && uint64(c.Ub) >= POW_16 { //if upper bound is at lest 16bits then set as semi-constrain
-		lRange = 0
+	return aw.WriteStringWith(content, len, NewPerConstraint(c, e), isBitstring)
+}
+
+// WriteStringWith encodes a BIT STRING (isBitstring, len in bits) or an OCTET
+// STRING (len in octets) whose size is constrained by pc.
+func (aw *AperWriter) WriteStringWith(content []byte, len uint64, pc *PerConstraint, isBitstring bool) (err error) {
+	lb, ub, hasUb, err := pc.SizeBounds()
+	if err != nil {
+		return
+	}
+	inRoot := len >= lb && (!hasUb || len <= ub)
+	if pc.IsExtensible() {
+		if err = aw.WriteBool(!inRoot); err != nil {
+			return
+		}
 	}
-	if lRange == 1 { //constrain with fixed length; both bounds have the same value
-		if int64(len) != lowerBound {
-			err = ErrFixedLength
+	if !inRoot {
+		if !pc.IsExtensible() {
+			if hasUb && lb == ub {
+				err = ErrFixedLength
+			} else {
+				err = ErrInextensible
+			}
 			return
 		}
+		//outside the extension root the size is semi-constrained
+		lb, hasUb = 0, false
+	}
+
+	if hasUb && ub < POW_16 {
 		var numByte, nbits uint64
 		if isBitstring {
 			numByte = (len + 7) >> 3
@@ -157,40 +237,46 @@ func (aw *AperWriter) WriteString(content []byte, len uint64, c *Constraint, e b
 			numByte = len
 			nbits = len * 8
 		}
-		if numByte > 2 { //if more than 2 bytes, align first
-			if err = aw.align(); err != nil {
-				return
+		if lb == ub { //constrain with fixed length; both bounds have the same value
+			if numByte > 2 { //if more than 2 bytes, align first
+				if err = aw.align(); err != nil {
+					return
+				}
 			}
+			//then write content
+			err = aw.WriteBits(content, uint(nbits))
+			return
+		}
+		//length is a constrained whole number, content is octet-aligned
+		if err = aw.writeConstraintValue(ub-lb+1, len-lb); err != nil {
+			return
+		}
+		if nbits == 0 {
+			return
+		}
+		if err = aw.align(); err != nil {
+			return
 		}
-		//then write content
 		err = aw.WriteBits(content, uint(nbits))
 		return
 	}
+
+	//semi-constrained or unconstrained length, fragmented in 16K blocks
 	partReader := NewBitStreamReader(bytes.NewReader(content)) //for reading parts of content for writing
-	totalLen := uint64(len) - uint64(lowerBound)
+	totalLen := len
 	var partLen uint64
 	var partBytes []byte
 	completed := false
 	for {
-		if totalLen > POW_16 {
-			partLen = POW_16
-		} else if totalLen >= POW_14 {
-			partLen = totalLen & 0xc000 //strip last 14 bits, keep bit 14,15.
-		} else {
-			partLen = totalLen
-			completed = true //last part to write
-			//Last part can have zero length, still it must be encoded to tell
-			//reader (decoder) to stop
-		}
+		partLen, completed = fragmentLength(totalLen)
 		totalLen -= partLen //reduce total length
 
 		//encode length
-		if err = aw.writeLength(uint64(lRange), partLen); err != nil {
+		if err = aw.writeLength(0, partLen); err != nil {
 			return
 		}
 
 		//write content part
-		partLen += uint64(lowerBound)
 		if partLen == 0 {
 			return
 		}
@@ -219,43 +305,61 @@ func (aw *AperWriter) WriteString(content []byte, len uint64, c *Constraint, e b
 }
 
 func (aw *AperWriter) WriteBitString(content []byte, nbits uint, c *Constraint, e bool) (err error) {
+	return aw.WriteBitStringWith(content, nbits, NewPerConstraint(c, e))
+}
+
+func (aw *AperWriter) WriteBitStringWith(content []byte, nbits uint, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteBitString", err)
 	}()
-	err = aw.WriteString(content, uint64(nbits), c, e, true)
+	err = aw.WriteStringWith(content, uint64(nbits), pc, true)
 	return
 }
 
 func (aw *AperWriter) WriteOctetString(content []byte, c *Constraint, e bool) (err error) {
+	return aw.WriteOctetStringWith(content, NewPerConstraint(c, e))
+}
+
+func (aw *AperWriter) WriteOctetStringWith(content []byte, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteOctetString", err)
 	}()
 	byteLen := uint64(len(content))
-	err = aw.WriteString(content, byteLen, c, e, false)
+	err = aw.WriteStringWith(content, byteLen, pc, false)
 	return
 }
 
 // constrain must have Lb <= Ub
 func (aw *AperWriter) WriteEnumerate(v uint64, c Constraint, e bool) (err error) {
+	return aw.WriteEnumerateWith(v, NewPerConstraint(&c, e))
+}
+
+// WriteEnumerateWith encodes an enumeration index; pc must have both bounds.
+// Indexes above the root upper bound are extension additions.
+func (aw *AperWriter) WriteEnumerateWith(v uint64, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteEnumerate", err)
 	}()
+	if pc.Category() != Constrained || pc.Lb < 0 || pc.Validate() != nil {
+		err = ErrConstraint
+		return
+	}
 
-	if v <= uint64(c.Ub) { //value is in range
-		if e {
+	if v <= uint64(pc.Ub) { //value is in range
+		if pc.Extensible {
 			if err = aw.WriteBool(Zero); err != nil {
 				return
 			}
 		}
-		vRange := c.Range()
+		vRange := pc.Range()
 		if vRange > 1 {
-			err = aw.writeConstraintValue(vRange, v-uint64(c.Lb))
+			err = aw.writeConstraintValue(vRange, v-uint64(pc.Lb))
 			return
 		}
 		//in case Lb == Ub, no need to write value, when reading, just use the
 		//bound value
 	} else { //value is of of range
-		if !e { //not extensible
+		if !pc.Extensible { //not extensible
 			err = ErrInextensible
 			return
 		}
@@ -263,7 +367,7 @@ func (aw *AperWriter) WriteEnumerate(v uint64, c Constraint, e bool) (err error)
 		if err = aw.WriteBool(One); err != nil {
 			return
 		}
-		err = aw.writeNormallySmallNonNegativeValue(v - uint64(c.Ub) - 1)
+		err = aw.writeNormallySmallNonNegativeValue(v - uint64(pc.Ub) - 1)
 	}
 
 	return
@@ -279,59 +383,64 @@ func (aw *AperWriter) WriteOpenType(content []byte) (err error) {
 	return
 }
 
-func (aw *AperWriter) WriteInteger(v int64, c *Constraint, e bool) (err error) {
+// WriteOpenTypeValue encodes value on its own and writes the complete
+// encoding as an open type.
+func (aw *AperWriter) WriteOpenTypeValue(value AperMarshaller) (err error) {
 	defer func() {
-		err = utils.WrapError("WriteInteger", err)
+		err = utils.WrapError("WriteOpenTypeValue", err)
 	}()
-	lb, sRange, _ := aw.writeExtBit(uint64(v), e, c)
-	unsignedValue := uint64(v)
-	var rawLength uint
-	if sRange == 1 {
-		return nil
+	var content []byte
+	if content, err = marshal(value, aw.canonical); err != nil {
+		return
 	}
+	err = aw.WriteOpenType(content)
+	return
+}
 
-	if v < 0 {
-		y := v >> 63
-		unsignedValue = uint64(((v ^ y) - y)) - 1
+func (aw *AperWriter) WriteInteger(v int64, c *Constraint, e bool) (err error) {
+	return aw.WriteIntegerWith(v, NewPerConstraint(c, e))
+}
+
+// WriteIntegerWith encodes an INTEGER as a constrained, semi-constrained or
+// unconstrained whole number depending on the category of pc.
+func (aw *AperWriter) WriteIntegerWith(v int64, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteInteger", err)
+	}()
+	if err = pc.Validate(); err != nil {
+		return
 	}
-	if sRange <= 0 {
-		unsignedValue >>= 7
-	} else if sRange <= 65536 {
-		return aw.writeConstraintValue(uint64(sRange), uint64(v-lb))
-	} else {
-		unsignedValue >>= 8
+	inRoot := pc.InRoot(v)
+	if !inRoot && !pc.InExtension(v) {
+		err = ErrInextensible
+		return
 	}
-
-	for rawLength = 1; rawLength <= 127; rawLength++ {
-		if unsignedValue == 0 {
-			break
+	if pc.IsExtensible() {
+		if err = aw.WriteBool(!inRoot); err != nil {
+			return
 		}
-		unsignedValue >>= 8
 	}
-	// write length
-	if sRange <= 0 {
-		aw.align()
-		_ = aw.writeBytes([]byte{byte(rawLength)})
-	} else {
-		unsignedValueRange := uint64(sRange - 1)
-		bitLen := bits.Len64(unsignedValueRange)
-		byteLen := uint((bitLen + 7) / 8)
-		bitLenngth := bits.Len(uint(int(byteLen)))
-		if err := aw.writeValue(uint64(rawLength-1), uint(bitLenngth)); err != nil {
-			return err
-		}
+	if !inRoot {
+		//extension values are encoded as unconstrained whole numbers
+		err = aw.writeUnconstrainedWholeNumber(v)
+		return
 	}
-	rawLength *= 8
-	aw.align()
-	if sRange < 0 {
-		mask := int64(1<<rawLength - 1)
-		return aw.writeValue(uint64(v&mask), rawLength)
-	} else {
-		v -= lb
-		return aw.writeValue(uint64(v), rawLength)
+
+	switch pc.Category() {
+	case Constrained:
+		err = aw.writeConstrainedWholeNumber(uint64(v)-uint64(pc.Lb), pc.Span())
+	case SemiConstrained:
+		err = aw.writeSemiConstraintWholeNumber(uint64(v)-uint64(pc.Lb), 0)
+	default:
+		err = aw.writeUnconstrainedWholeNumber(v)
 	}
+	return
 }
 
+// WriteChoice writes the index v (starting from 1) of a CHOICE alternative;
+// uBound is the number of root alternatives minus one. An index beyond the
+// root selects an extension alternative, whose value must then be written as
+// an open type (see WriteOpenTypeValue and WriteChoiceValue).
 func (aw *AperWriter) WriteChoice(v uint64, uBound uint64, e bool) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteChoice", err)
@@ -341,20 +450,37 @@ func (aw *AperWriter) WriteChoice(v uint64, uBound uint64, e bool) (err error) {
 		return
 	}
 	v -= 1
-	if v > uBound {
-		err = fmt.Errorf("Choice extension not supported")
+	isExtension := v > uBound
+	if isExtension && !e {
+		err = ErrInextensible
 		return
 	}
 
-	if e && v > uBound {
-		if err = aw.WriteBool(Zero); err != nil {
+	if e {
+		if err = aw.WriteBool(isExtension); err != nil {
 			return
 		}
 	}
+	if isExtension {
+		err = aw.writeNormallySmallNonNegativeValue(v - uBound - 1)
+		return
+	}
 	err = aw.writeConstraintValue(uBound+1, v)
 	return
 }
 
+// WriteChoiceValue writes a CHOICE alternative and its value: a root value
+// directly, an extension value wrapped in an open type.
+func (aw *AperWriter) WriteChoiceValue(v uint64, uBound uint64, e bool, value AperMarshaller) (err error) {
+	if err = aw.WriteChoice(v, uBound, e); err != nil {
+		return
+	}
+	if v-1 > uBound {
+		return aw.WriteOpenTypeValue(value)
+	}
+	return value.Encode(aw)
+}
+
 // WriteBoolean encodes an ASN.1 BOOLEAN value according to APER rules.
 // A BOOLEAN is encoded as a single bit: 1 for true, 0 for false.
 func (aw *AperWriter) WriteBoolean(value bool) (err error) {
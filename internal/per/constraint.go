@@ -0,0 +1,189 @@
+// Package per holds the parts of X.691 that the ALIGNED and UNALIGNED
+// variants share: the PER-visible constraints, the ordering of SET OF
+// components and the component machinery of a SEQUENCE. The aper and uper
+// packages re-export them under their own names.
+package per
+
+import "fmt"
+
+// ErrConstraint is returned for a constraint whose bounds are inconsistent.
+var ErrConstraint error = fmt.Errorf("Invalid constraint")
+
+// Constraint is a fixed range (lb..ub), the root of a legacy constraint or an
+// extension-addition range of a PerConstraint.
+type Constraint struct {
+	Lb int64
+	Ub int64
+}
+
+func (c *Constraint) Range() uint64 {
+	if c.Lb > c.Ub {
+		return 0
+	}
+	return uint64(c.Ub - c.Lb + 1)
+}
+
+// ConstraintCategory is the X.691 category selected by a PER-visible
+// constraint: it decides how a whole number or a length is encoded.
+type ConstraintCategory uint8
+
+const (
+	Unconstrained   ConstraintCategory = iota // no lower bound: (MIN..MAX) or (MIN..ub)
+	SemiConstrained                           // lower bound only: (lb..MAX)
+	Constrained                               // both bounds: (lb..ub)
+)
+
+// PerConstraint is a PER-visible constraint on an INTEGER value or on the
+// size of a string or a list. Unlike Constraint, either bound may be left
+// open (MIN/MAX) and the extension marker is part of the constraint itself.
+//
+//	INTEGER (0..65535, ...)      Bounded(0, 65535).Extend()
+//	INTEGER (0..MAX)             AtLeast(0)
+//	INTEGER (MIN..10)            AtMost(10)
+//	SIZE (1..MAX)                AtLeast(1)
+//	INTEGER (0..10, ..., 20..30) Bounded(0, 10).Extend(Constraint{Lb: 20, Ub: 30})
+type PerConstraint struct {
+	Lb    int64
+	Ub    int64
+	HasLb bool //false means MIN
+	HasUb bool //false means MAX
+
+	Extensible bool
+	Additions  []Constraint //extension-addition ranges; empty means any value
+}
+
+// Bounded returns the fully constrained range (lb..ub).
+func Bounded(lb, ub int64) *PerConstraint {
+	return &PerConstraint{Lb: lb, Ub: ub, HasLb: true, HasUb: true}
+}
+
+// AtLeast returns the semi-constrained range (lb..MAX).
+func AtLeast(lb int64) *PerConstraint {
+	return &PerConstraint{Lb: lb, HasLb: true}
+}
+
+// AtMost returns the range (MIN..ub), which PER encodes as unconstrained.
+func AtMost(ub int64) *PerConstraint {
+	return &PerConstraint{Ub: ub, HasUb: true}
+}
+
+// Unbounded returns the range (MIN..MAX).
+func Unbounded() *PerConstraint {
+	return &PerConstraint{}
+}
+
+// NewPerConstraint converts the legacy (Constraint, extensible) pair used by
+// the older Write*/Read* signatures. A nil c means no constraint.
+func NewPerConstraint(c *Constraint, e bool) *PerConstraint {
+	if c == nil {
+		return &PerConstraint{Extensible: e}
+	}
+	return &PerConstraint{Lb: c.Lb, Ub: c.Ub, HasLb: true, HasUb: true, Extensible: e}
+}
+
+// Extend returns a copy of the constraint with an extension marker and the
+// given extension-addition ranges.
+func (pc PerConstraint) Extend(additions ...Constraint) *PerConstraint {
+	pc.Extensible = true
+	pc.Additions = append([]Constraint(nil), additions...)
+	return &pc
+}
+
+// Category reports how a value constrained by pc is encoded. A nil
+// constraint is unconstrained.
+func (pc *PerConstraint) Category() ConstraintCategory {
+	switch {
+	case pc == nil || !pc.HasLb:
+		return Unconstrained
+	case !pc.HasUb:
+		return SemiConstrained
+	default:
+		return Constrained
+	}
+}
+
+// Span returns ub-lb, i.e. the range minus one, which always fits in 64 bits.
+// It is only meaningful for a Constrained constraint.
+func (pc *PerConstraint) Span() uint64 {
+	return uint64(pc.Ub) - uint64(pc.Lb)
+}
+
+// Range returns ub-lb+1, or 0 if the range is not bounded or does not fit in
+// 64 bits.
+func (pc *PerConstraint) Range() uint64 {
+	if pc.Category() != Constrained || pc.Lb > pc.Ub {
+		return 0
+	}
+	return pc.Span() + 1
+}
+
+// InRoot reports whether v satisfies the root (non-extension) constraint.
+func (pc *PerConstraint) InRoot(v int64) bool {
+	if pc == nil {
+		return true
+	}
+	if pc.HasLb && v < pc.Lb {
+		return false
+	}
+	if pc.HasUb && v > pc.Ub {
+		return false
+	}
+	return true
+}
+
+// InExtension reports whether v is outside the root but allowed by the
+// extension: any such value if no addition ranges are listed, otherwise a
+// value inside one of them.
+func (pc *PerConstraint) InExtension(v int64) bool {
+	if !pc.IsExtensible() || pc.InRoot(v) {
+		return false
+	}
+	if len(pc.Additions) == 0 {
+		return true
+	}
+	for _, r := range pc.Additions {
+		if v >= r.Lb && v <= r.Ub {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExtensible reports whether pc carries an extension marker.
+func (pc *PerConstraint) IsExtensible() bool {
+	return pc != nil && pc.Extensible
+}
+
+// Validate checks that the bounds are consistent.
+func (pc *PerConstraint) Validate() error {
+	if pc != nil && pc.HasLb && pc.HasUb && pc.Lb > pc.Ub {
+		return ErrConstraint
+	}
+	return nil
+}
+
+// SizeBounds returns the effective bounds of a SIZE constraint: a missing
+// lower bound is zero and a missing upper bound is reported by hasUb=false.
+func (pc *PerConstraint) SizeBounds() (lb uint64, ub uint64, hasUb bool, err error) {
+	if pc == nil {
+		return
+	}
+	if err = pc.Validate(); err != nil {
+		return
+	}
+	if pc.HasLb {
+		if pc.Lb < 0 {
+			err = ErrConstraint
+			return
+		}
+		lb = uint64(pc.Lb)
+	}
+	if pc.HasUb {
+		if pc.Ub < 0 {
+			err = ErrConstraint
+			return
+		}
+		ub, hasUb = uint64(pc.Ub), true
+	}
+	return
+}
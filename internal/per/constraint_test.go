@@ -0,0 +1,35 @@
+package per
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPerConstraint_SizeBounds(t *testing.T) {
+	tests := []struct {
+		name  string
+		pc    *PerConstraint
+		lb    uint64
+		ub    uint64
+		hasUb bool
+		err   error
+	}{
+		{"nil", nil, 0, 0, false, nil},
+		{"SIZE(1..8)", Bounded(1, 8), 1, 8, true, nil},
+		{"SIZE(2..MAX)", AtLeast(2), 2, 0, false, nil},
+		{"SIZE(MIN..8)", AtMost(8), 0, 8, true, nil},
+		{"inverted", Bounded(8, 1), 0, 0, false, ErrConstraint},
+		{"negative", Bounded(-1, 8), 0, 0, false, ErrConstraint},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb, ub, hasUb, err := tt.pc.SizeBounds()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("SizeBounds() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && (lb != tt.lb || ub != tt.ub || hasUb != tt.hasUb) {
+				t.Errorf("SizeBounds() = %d, %d, %v, want %d, %d, %v", lb, ub, hasUb, tt.lb, tt.ub, tt.hasUb)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package per
+
+import "fmt"
+
+// ErrNonCanonical is returned by a canonical reader for an encoding that
+// CANONICAL-PER does not allow.
+var ErrNonCanonical error = fmt.Errorf("Non-canonical encoding")
+
+// SequenceField is a SEQUENCE component to encode with a writer W. An
+// OPTIONAL component, or one with a DEFAULT value, is declared Optional and
+// only encoded if Present. A component with a DEFAULT value sets IsDefault
+// when its value equals the default; whether it is then left out is decided
+// by the DefaultPolicy of the encoder.
+type SequenceField[W any] struct {
+	Optional  bool
+	Present   bool
+	IsDefault bool
+	Encode    func(w W) error
+}
+
+// DefaultPolicy tells an encoder what to do with a component whose value
+// equals its DEFAULT.
+type DefaultPolicy uint8
+
+const (
+	// DefaultCanonical leaves such a component out, as CANONICAL-PER requires.
+	DefaultCanonical DefaultPolicy = iota
+	// DefaultBasic encodes such a component if it is Present, which
+	// BASIC-PER allows.
+	DefaultBasic
+)
+
+// DefaultFieldFunc declares a component holding v with the DEFAULT value def,
+// compared by equal; encode writes a value of the component.
+func DefaultFieldFunc[W, T any](v, def T, equal func(a, b T) bool, encode func(w W, v T) error) SequenceField[W] {
+	return SequenceField[W]{
+		Optional:  true,
+		Present:   true,
+		IsDefault: equal(v, def),
+		Encode:    func(w W) error { return encode(w, v) },
+	}
+}
+
+// ApplyDefaults returns a copy of fields in which the components that policy
+// leaves out are not Present.
+func ApplyDefaults[W any](fields []SequenceField[W], policy DefaultPolicy) []SequenceField[W] {
+	fields = append([]SequenceField[W](nil), fields...)
+	for i := range fields {
+		if fields[i].IsDefault && policy == DefaultCanonical {
+			fields[i].Present = false
+		}
+	}
+	return fields
+}
+
+// GroupPresent reports whether any component of an extension group is present.
+func GroupPresent[W any](group []SequenceField[W]) bool {
+	for _, f := range group {
+		if f.Present {
+			return true
+		}
+	}
+	return false
+}
+
+// BitWriter is the part of a writer that encodes a presence preamble.
+type BitWriter interface {
+	WriteBool(v bool) error
+}
+
+// EncodeFields encodes a list of components after their presence preamble.
+func EncodeFields[W BitWriter](w W, fields []SequenceField[W]) (err error) {
+	for _, f := range fields {
+		if f.Optional {
+			if err = w.WriteBool(f.Present); err != nil {
+				return
+			}
+		}
+	}
+	for _, f := range fields {
+		if f.Optional && !f.Present {
+			continue
+		}
+		if err = f.Encode(w); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// SequenceFieldDecoder is a SEQUENCE component to decode with a reader R.
+// Decode is only called if the component is present; otherwise Default, if
+// set, fills in the DEFAULT value of the component. IsDefault, if set,
+// reports whether the decoded value equals the DEFAULT value, which a
+// canonical reader rejects.
+type SequenceFieldDecoder[R any] struct {
+	Optional  bool
+	Decode    func(r R) error
+	Default   func()
+	IsDefault func() bool
+}
+
+// DefaultFieldDecoderFunc declares a component with the DEFAULT value def,
+// compared by equal, decoded into v by decode.
+func DefaultFieldDecoderFunc[R, T any](v *T, def T, equal func(a, b T) bool, decode func(r R) (T, error)) SequenceFieldDecoder[R] {
+	return SequenceFieldDecoder[R]{
+		Optional: true,
+		Decode: func(r R) (err error) {
+			*v, err = decode(r)
+			return
+		},
+		Default:   func() { *v = def },
+		IsDefault: func() bool { return equal(*v, def) },
+	}
+}
+
+// BitReader is the part of a reader that decodes a presence preamble.
+type BitReader interface {
+	ReadBool() (bool, error)
+	Canonical() bool
+}
+
+// DecodeFields decodes a list of components after their presence preamble.
+func DecodeFields[R BitReader](r R, fields []SequenceFieldDecoder[R]) (err error) {
+	present := make([]bool, len(fields))
+	for i, f := range fields {
+		present[i] = true
+		if f.Optional {
+			if present[i], err = r.ReadBool(); err != nil {
+				return
+			}
+		}
+	}
+	for i, f := range fields {
+		if !present[i] {
+			if f.Default != nil {
+				f.Default()
+			}
+			continue
+		}
+		if err = f.Decode(r); err != nil {
+			return
+		}
+		if r.Canonical() && f.IsDefault != nil && f.IsDefault() {
+			err = ErrNonCanonical
+			return
+		}
+	}
+	return
+}
+
+// SetDefaults fills in the DEFAULT values of the components of an absent
+// extension group.
+func SetDefaults[R any](fields []SequenceFieldDecoder[R]) {
+	for _, f := range fields {
+		if f.Default != nil {
+			f.Default()
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package per
+
+import (
+	"bytes"
+	"sort"
+)
+
+// SetOfOrder returns the indexes of the keys, the padded canonical encodings
+// of the components of a SET OF, in the canonical order of the components:
+// ascending, equal keys keeping their relative order (X.691 clause 22).
+func SetOfOrder(keys [][]byte) []int {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return CompareSetOfKeys(keys[order[i]], keys[order[j]]) < 0
+	})
+	return order
+}
+
+// CompareSetOfKeys compares two keys as octet strings, the shorter one
+// extended with 0 octets to the length of the longer.
+func CompareSetOfKeys(a, b []byte) int {
+	n := min(len(a), len(b))
+	if c := bytes.Compare(a[:n], b[:n]); c != 0 {
+		return c
+	}
+	for _, o := range a[n:] {
+		if o != 0 {
+			return 1
+		}
+	}
+	for _, o := range b[n:] {
+		if o != 0 {
+			return -1
+		}
+	}
+	return 0
+}
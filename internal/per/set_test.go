@@ -0,0 +1,31 @@
+package per
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareSetOfKeys(t *testing.T) {
+	tests := []struct {
+		a, b []byte
+		want int
+	}{
+		{[]byte{0x01}, []byte{0x02}, -1},
+		{[]byte{0x01, 0x00}, []byte{0x01}, 0},
+		{[]byte{0x01, 0x01}, []byte{0x01}, 1},
+		{[]byte{0x01}, []byte{0x01, 0x01}, -1},
+		{nil, []byte{0x00}, 0},
+	}
+	for _, tt := range tests {
+		if got := CompareSetOfKeys(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareSetOfKeys(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSetOfOrder(t *testing.T) {
+	keys := [][]byte{{0x02}, {0x01, 0x00}, {0x01}, {0x00, 0xff}}
+	if got := SetOfOrder(keys); !reflect.DeepEqual(got, []int{3, 1, 2, 0}) {
+		t.Errorf("SetOfOrder() = %v, want [3 1 2 0]", got)
+	}
+}
@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"github.com/lvdund/asn1go/aper"
+	"github.com/lvdund/asn1go/utils"
+)
+
+var (
+	aperIeIDConstraint         = aper.Constraint{Lb: 0, Ub: 65535}
+	aperCriticalityConstraint  = aper.Constraint{Lb: 0, Ub: 2}
+	aperContainerSize          = aper.Constraint{Lb: 0, Ub: MaxProtocolIEs}
+	aperExtensionContainerSize = aper.Constraint{Lb: 1, Ub: MaxProtocolExtensions}
+)
+
+// AperProtocolIEField is an IE of a container of the set S in the aligned
+// variant. Value is nil for an IE whose id is not registered, whose open type
+// octets are kept in Raw instead; Decode then returns an *UnknownIEError if
+// the IE is of criticality reject.
+type AperProtocolIEField[S any] struct {
+	ID          int64
+	Criticality Criticality
+	Value       aper.IE
+	Raw         []byte
+}
+
+func (f *AperProtocolIEField[S]) Encode(aw *aper.AperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("ProtocolIEField", err)
+	}()
+	if err = aw.WriteInteger(f.ID, &aperIeIDConstraint, false); err != nil {
+		return
+	}
+	if err = aw.WriteEnumerate(uint64(f.Criticality), aperCriticalityConstraint, false); err != nil {
+		return
+	}
+	if f.Value == nil {
+		err = aw.WriteOpenType(f.Raw)
+		return
+	}
+	err = aw.WriteOpenTypeValue(f.Value)
+	return
+}
+
+func (f *AperProtocolIEField[S]) Decode(ar *aper.AperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("ProtocolIEField", err)
+	}()
+	*f = AperProtocolIEField[S]{}
+	if f.ID, err = ar.ReadInteger(&aperIeIDConstraint, false); err != nil {
+		return
+	}
+	var criticality uint64
+	if criticality, err = ar.ReadEnumerate(aperCriticalityConstraint, false); err != nil {
+		return
+	}
+	f.Criticality = Criticality(criticality)
+	entry, ok := TableOf[S, aper.IE]().Lookup(f.ID)
+	if !ok {
+		if f.Raw, err = ar.ReadOpenType(); err == nil {
+			err = unknownIE(f.ID, f.Criticality)
+		}
+		return
+	}
+	f.Value = entry.New()
+	err = ar.ReadOpenTypeValue(f.Value)
+	return
+}
+
+// AperProtocolIESingleContainer is a ProtocolIE-SingleContainer of the set S,
+// a single ProtocolIE-Field, in the aligned variant.
+type AperProtocolIESingleContainer[S any] = AperProtocolIEField[S]
+
+// AperProtocolIEContainer is a ProtocolIE-Container of the set S, SEQUENCE
+// (SIZE (0..maxProtocolIEs)) OF ProtocolIE-Field, in the aligned variant.
+type AperProtocolIEContainer[S any] struct {
+	List []AperProtocolIEField[S]
+}
+
+// Add appends the IE id with the criticality it is registered with.
+func (c *AperProtocolIEContainer[S]) Add(id int64, value aper.IE) (err error) {
+	c.List, err = aperAddField(c.List, id, value)
+	return utils.WrapError("ProtocolIEContainer", err)
+}
+
+// Get returns the first IE with the given id.
+func (c *AperProtocolIEContainer[S]) Get(id int64) (*AperProtocolIEField[S], bool) {
+	return aperGetField(c.List, id)
+}
+
+func (c *AperProtocolIEContainer[S]) Encode(aw *aper.AperWriter) error {
+	return utils.WrapError("ProtocolIEContainer", aperEncodeFields(c.List, aw, &aperContainerSize))
+}
+
+func (c *AperProtocolIEContainer[S]) Decode(ar *aper.AperReader) (err error) {
+	c.List, err = aperDecodeFields[S](ar, &aperContainerSize)
+	return utils.WrapError("ProtocolIEContainer", err)
+}
+
+// AperProtocolExtensionField is a ProtocolExtensionField of the set S in the
+// aligned variant. It is encoded like a ProtocolIE-Field, Value holding the
+// extensionValue.
+type AperProtocolExtensionField[S any] = AperProtocolIEField[S]
+
+// AperProtocolExtensionContainer is a ProtocolExtensionContainer of the set S,
+// SEQUENCE (SIZE (1..maxProtocolExtensions)) OF ProtocolExtensionField, in
+// the aligned variant. Its extensions are registered in S like IEs; as the
+// container cannot be empty it is normally held by pointer, nil when absent.
+type AperProtocolExtensionContainer[S any] struct {
+	List []AperProtocolExtensionField[S]
+}
+
+// Add appends the extension id with the criticality it is registered with.
+func (c *AperProtocolExtensionContainer[S]) Add(id int64, value aper.IE) (err error) {
+	c.List, err = aperAddField(c.List, id, value)
+	return utils.WrapError("ProtocolExtensionContainer", err)
+}
+
+// Get returns the first extension with the given id.
+func (c *AperProtocolExtensionContainer[S]) Get(id int64) (*AperProtocolExtensionField[S], bool) {
+	return aperGetField(c.List, id)
+}
+
+func (c *AperProtocolExtensionContainer[S]) Encode(aw *aper.AperWriter) error {
+	return utils.WrapError("ProtocolExtensionContainer", aperEncodeFields(c.List, aw, &aperExtensionContainerSize))
+}
+
+func (c *AperProtocolExtensionContainer[S]) Decode(ar *aper.AperReader) (err error) {
+	c.List, err = aperDecodeFields[S](ar, &aperExtensionContainerSize)
+	return utils.WrapError("ProtocolExtensionContainer", err)
+}
+
+func aperAddField[S any](list []AperProtocolIEField[S], id int64, value aper.IE) ([]AperProtocolIEField[S], error) {
+	criticality, err := criticalityOf[S, aper.IE](id)
+	if err != nil {
+		return list, err
+	}
+	return append(list, AperProtocolIEField[S]{ID: id, Criticality: criticality, Value: value}), nil
+}
+
+func aperGetField[S any](list []AperProtocolIEField[S], id int64) (*AperProtocolIEField[S], bool) {
+	for i := range list {
+		if list[i].ID == id {
+			return &list[i], true
+		}
+	}
+	return nil, false
+}
+
+// aperEncodeFields writes a list of fields whose size is constrained by size.
+func aperEncodeFields[S any](list []AperProtocolIEField[S], aw *aper.AperWriter, size *aper.Constraint) error {
+	fields := make([]*AperProtocolIEField[S], len(list))
+	for i := range list {
+		fields[i] = &list[i]
+	}
+	return aper.NewListContainer(fields, size, false).Encode(aw)
+}
+
+// aperDecodeFields reads a list of fields whose size is constrained by size.
+// The IEs of criticality reject whose id is not registered do not stop the
+// decoding: the whole list is returned along with their errors.
+func aperDecodeFields[S any](ar *aper.AperReader, size *aper.Constraint) (list []AperProtocolIEField[S], err error) {
+	var unknown unknownIEs
+	decode := func(ar *aper.AperReader) (*AperProtocolIEField[S], error) {
+		f := new(AperProtocolIEField[S])
+		return f, unknown.keep(f.Decode(ar))
+	}
+	for f, err := range aper.ReadSequenceOfSeq(decode, ar, size, false) {
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	err = unknown.err()
+	return
+}
@@ -0,0 +1,177 @@
+// Package registry implements the information object sets that select the
+// type of an open type at run time, such as the ProtocolIE-Field of NGAP,
+// F1AP, XnAP or E2AP:
+//
+//	ProtocolIE-Field ::= SEQUENCE {
+//		id          ProtocolIE-ID,
+//		criticality Criticality,
+//		value       OPEN TYPE }
+//
+// A protocol package declares one type per container and registers the IEs
+// the container may hold, typically in an init function:
+//
+//	type InitialUEMessageIEs struct{}
+//
+//	func init() {
+//		registry.Register[InitialUEMessageIEs](85, registry.Reject,
+//			func() aper.IE { return new(RANUENGAPID) })
+//	}
+//
+// A registry.AperProtocolIEContainer[InitialUEMessageIEs] then decodes the
+// values of the registered ids and keeps the others as raw octets, reporting
+// those of criticality reject with an *UnknownIEError; the unaligned variant
+// is UperProtocolIEContainer, whose IEs are registered as uper.IE. The
+// extensions of a ProtocolExtensionContainer are registered the same way,
+// under a type of their own.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Size bounds of the containers of the 3GPP protocols.
+const (
+	MaxProtocolIEs        = 65535
+	MaxProtocolExtensions = 65535
+)
+
+// ErrUnregisteredID is returned when adding an IE whose id is not registered
+// in the set of the container.
+var ErrUnregisteredID error = fmt.Errorf("Unregistered IE id")
+
+// UnknownIEError is returned when decoding an IE whose id is not registered
+// while its criticality is reject, which obliges the receiver to reject the
+// message. The IE is kept raw all the same and the rest of its container is
+// decoded, so that the caller can still build the error indication; several
+// such IEs are reported together with errors.Join.
+type UnknownIEError struct {
+	ID          int64
+	Criticality Criticality
+}
+
+func (e *UnknownIEError) Error() string {
+	return fmt.Sprintf("Unknown IE %d of criticality %s", e.ID, e.Criticality)
+}
+
+// Criticality tells a receiver what to do with an IE it does not comprehend.
+type Criticality uint64
+
+const (
+	Reject Criticality = iota
+	Ignore
+	Notify
+)
+
+func (c Criticality) String() string {
+	switch c {
+	case Reject:
+		return "reject"
+	case Ignore:
+		return "ignore"
+	case Notify:
+		return "notify"
+	}
+	return fmt.Sprintf("Criticality(%d)", uint64(c))
+}
+
+// Entry is a registered IE: its criticality and a factory of the values to
+// decode into.
+type Entry[F any] struct {
+	Criticality Criticality
+	New         func() F
+}
+
+// Table maps the ids of a container to their entries; F is the interface the
+// values implement, aper.IE or uper.IE.
+type Table[F any] struct {
+	mu      sync.RWMutex
+	entries map[int64]Entry[F]
+}
+
+// Register adds the IE id to t. Registering an id twice is a programming
+// error and panics.
+func (t *Table[F]) Register(id int64, criticality Criticality, newValue func() F) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.entries[id]; ok {
+		panic(fmt.Sprintf("registry: IE %d registered twice", id))
+	}
+	if t.entries == nil {
+		t.entries = make(map[int64]Entry[F])
+	}
+	t.entries[id] = Entry[F]{Criticality: criticality, New: newValue}
+}
+
+// Lookup returns the entry of the IE id.
+func (t *Table[F]) Lookup(id int64) (e Entry[F], ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e, ok = t.entries[id]
+	return
+}
+
+type tableKey struct {
+	set, value reflect.Type
+}
+
+var (
+	tablesMu sync.Mutex
+	tables   = make(map[tableKey]any)
+)
+
+// TableOf returns the table shared by every container of the set S whose
+// values implement F.
+func TableOf[S, F any]() *Table[F] {
+	key := tableKey{reflect.TypeFor[S](), reflect.TypeFor[F]()}
+	tablesMu.Lock()
+	defer tablesMu.Unlock()
+	t, ok := tables[key].(*Table[F])
+	if !ok {
+		t = new(Table[F])
+		tables[key] = t
+	}
+	return t
+}
+
+// Register adds the IE id to the set S. F is inferred from newValue.
+func Register[S, F any](id int64, criticality Criticality, newValue func() F) {
+	TableOf[S, F]().Register(id, criticality, newValue)
+}
+
+// criticalityOf returns the criticality the IE id is registered with in the
+// set S.
+func criticalityOf[S, F any](id int64) (Criticality, error) {
+	entry, ok := TableOf[S, F]().Lookup(id)
+	if !ok {
+		return 0, ErrUnregisteredID
+	}
+	return entry.Criticality, nil
+}
+
+// unknownIE returns the error of a decoded IE whose id is not registered.
+func unknownIE(id int64, criticality Criticality) error {
+	if criticality != Reject {
+		return nil
+	}
+	return &UnknownIEError{ID: id, Criticality: criticality}
+}
+
+// unknownIEs collects the UnknownIEErrors of the fields of a container, which
+// do not stop its decoding.
+type unknownIEs []error
+
+// keep records err if it is an *UnknownIEError and returns the other errors.
+func (u *unknownIEs) keep(err error) error {
+	if e := (*UnknownIEError)(nil); errors.As(err, &e) {
+		*u = append(*u, e)
+		return nil
+	}
+	return err
+}
+
+func (u unknownIEs) err() error {
+	return errors.Join(u...)
+}
@@ -0,0 +1,248 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/lvdund/asn1go/aper"
+	"github.com/lvdund/asn1go/uper"
+)
+
+// testIEs is a container with a reject IE 10, INTEGER (0..255), and an ignore
+// IE 11, OCTET STRING
+type testIEs struct{}
+
+// testExtensions is an extension set with an ignore extension 200, INTEGER
+// (0..255)
+type testExtensions struct{}
+
+func init() {
+	Register[testExtensions](200, Ignore, func() aper.IE { return &aper.ConstrainedInteger{C: &aper.Constraint{Lb: 0, Ub: 255}} })
+	Register[testExtensions](200, Ignore, func() uper.IE { return &uper.ConstrainedInteger{C: &uper.Constraint{Lb: 0, Ub: 255}} })
+	Register[testIEs](10, Reject, func() aper.IE { return &aper.ConstrainedInteger{C: &aper.Constraint{Lb: 0, Ub: 255}} })
+	Register[testIEs](11, Ignore, func() aper.IE { return new(aper.ConstrainedOctetString) })
+	Register[testIEs](10, Reject, func() uper.IE { return &uper.ConstrainedInteger{C: &uper.Constraint{Lb: 0, Ub: 255}} })
+	Register[testIEs](11, Ignore, func() uper.IE { return new(uper.ConstrainedOctetString) })
+}
+
+func TestAperProtocolIEContainer(t *testing.T) {
+	var c AperProtocolIEContainer[testIEs]
+	if err := c.Add(10, &aper.ConstrainedInteger{C: &aper.Constraint{Lb: 0, Ub: 255}, Value: 7}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add(11, &aper.ConstrainedOctetString{Value: []byte("ab")}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add(99, new(aper.ConstrainedOctetString)); !errors.Is(err, ErrUnregisteredID) {
+		t.Errorf("Add() error = %v, want %v", err, ErrUnregisteredID)
+	}
+	// an IE of a newer release, relayed as received
+	c.List = append(c.List, AperProtocolIEField[testIEs]{ID: 99, Criticality: Ignore, Raw: []byte{1}})
+
+	var buf bytes.Buffer
+	aw := aper.NewWriter(&buf)
+	if err := c.Encode(aw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := hex.EncodeToString(buf.Bytes()), "0003"+"000a000107"+"000b4003026162"+"0063400101"; got != want {
+		t.Errorf("Encode() = %s, want %s", got, want)
+	}
+
+	var decoded AperProtocolIEContainer[testIEs]
+	if err := decoded.Decode(aper.NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(decoded.List) != 3 {
+		t.Fatalf("Decode() returned %d IEs, want 3", len(decoded.List))
+	}
+	if f, ok := decoded.Get(10); !ok || f.Criticality != Reject || f.Value.(*aper.ConstrainedInteger).Value != 7 {
+		t.Errorf("IE 10 = %+v", f)
+	}
+	if f, ok := decoded.Get(11); !ok || f.Criticality != Ignore || string(f.Value.(*aper.ConstrainedOctetString).Value) != "ab" {
+		t.Errorf("IE 11 = %+v", f)
+	}
+	if f, ok := decoded.Get(99); !ok || f.Value != nil || !bytes.Equal(f.Raw, []byte{1}) {
+		t.Errorf("IE 99 = %+v", f)
+	}
+
+	var again bytes.Buffer
+	aw = aper.NewWriter(&again)
+	if err := decoded.Encode(aw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Errorf("re-encoded %x, received %x", again.Bytes(), buf.Bytes())
+	}
+}
+
+func TestUperProtocolIEContainer(t *testing.T) {
+	var c UperProtocolIEContainer[testIEs]
+	if err := c.Add(10, &uper.ConstrainedInteger{C: &uper.Constraint{Lb: 0, Ub: 255}, Value: 7}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add(11, &uper.ConstrainedOctetString{Value: []byte("ab")}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	c.List = append(c.List, UperProtocolIEField[testIEs]{ID: 99, Criticality: Ignore, Raw: []byte{1}})
+
+	var buf bytes.Buffer
+	uw := uper.NewWriter(&buf)
+	if err := c.Encode(uw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := hex.EncodeToString(buf.Bytes()), "0003000a0041c002d03026162006340404"; got != want {
+		t.Errorf("Encode() = %s, want %s", got, want)
+	}
+
+	var decoded UperProtocolIEContainer[testIEs]
+	if err := decoded.Decode(uper.NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if f, ok := decoded.Get(10); !ok || f.Value.(*uper.ConstrainedInteger).Value != 7 {
+		t.Errorf("IE 10 = %+v", f)
+	}
+	if f, ok := decoded.Get(99); !ok || f.Value != nil || !bytes.Equal(f.Raw, []byte{1}) {
+		t.Errorf("IE 99 = %+v", f)
+	}
+}
+
+// an unregistered IE of criticality reject is kept raw like the others, the
+// container is decoded whole and the IE reported
+func TestAperProtocolIEContainer_UnknownReject(t *testing.T) {
+	input, _ := hex.DecodeString("0002" + "0063000101" + "0062400101")
+	var decoded AperProtocolIEContainer[testIEs]
+	err := decoded.Decode(aper.NewReader(bytes.NewReader(input)))
+	var unknown *UnknownIEError
+	if !errors.As(err, &unknown) || unknown.ID != 99 || unknown.Criticality != Reject {
+		t.Fatalf("Decode() error = %v, want an UnknownIEError for IE 99", err)
+	}
+	if len(decoded.List) != 2 {
+		t.Fatalf("Decode() returned %d IEs, want 2", len(decoded.List))
+	}
+	if f, ok := decoded.Get(99); !ok || f.Value != nil || !bytes.Equal(f.Raw, []byte{1}) {
+		t.Errorf("IE 99 = %+v", f)
+	}
+	if f, ok := decoded.Get(98); !ok || f.Value != nil || !bytes.Equal(f.Raw, []byte{1}) {
+		t.Errorf("IE 98 = %+v", f)
+	}
+
+	var single AperProtocolIESingleContainer[testIEs]
+	if err := single.Decode(aper.NewReader(bytes.NewReader(input[2:]))); !errors.As(err, &unknown) || !bytes.Equal(single.Raw, []byte{1}) {
+		t.Errorf("Decode() = %+v, %v, want IE 99 and an UnknownIEError", single, err)
+	}
+}
+
+// the unaligned fields are 34 bits long, so the second one starts mid-octet
+func TestUperProtocolIEContainer_UnknownReject(t *testing.T) {
+	input, _ := hex.DecodeString("0002006300404018901010")
+	var decoded UperProtocolIEContainer[testIEs]
+	err := decoded.Decode(uper.NewReader(bytes.NewReader(input)))
+	var unknown *UnknownIEError
+	if !errors.As(err, &unknown) || unknown.ID != 99 {
+		t.Fatalf("Decode() error = %v, want an UnknownIEError for IE 99", err)
+	}
+	if f, ok := decoded.Get(98); !ok || len(decoded.List) != 2 || !bytes.Equal(f.Raw, []byte{1}) {
+		t.Errorf("Decode() = %+v", decoded.List)
+	}
+}
+
+func TestAperProtocolExtensionContainer(t *testing.T) {
+	var c AperProtocolExtensionContainer[testExtensions]
+	if err := c.Add(200, &aper.ConstrainedInteger{C: &aper.Constraint{Lb: 0, Ub: 255}, Value: 9}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add(10, new(aper.ConstrainedInteger)); err == nil {
+		t.Error("expected an error adding an IE of another set")
+	}
+	var buf bytes.Buffer
+	aw := aper.NewWriter(&buf)
+	if err := c.Encode(aw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// the count is encoded as count-1 for SIZE (1..maxProtocolExtensions)
+	if got, want := hex.EncodeToString(buf.Bytes()), "0000"+"00c8400109"; got != want {
+		t.Errorf("Encode() = %s, want %s", got, want)
+	}
+	var decoded AperProtocolExtensionContainer[testExtensions]
+	if err := decoded.Decode(aper.NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if f, ok := decoded.Get(200); !ok || f.Criticality != Ignore || f.Value.(*aper.ConstrainedInteger).Value != 9 {
+		t.Errorf("extension 200 = %+v", f)
+	}
+
+	var empty AperProtocolExtensionContainer[testExtensions]
+	if err := empty.Encode(aper.NewWriter(new(bytes.Buffer))); !errors.Is(err, aper.ErrUnderflow) {
+		t.Errorf("Encode() of an empty container error = %v, want ErrUnderflow", err)
+	}
+}
+
+func TestUperProtocolExtensionContainer(t *testing.T) {
+	var c UperProtocolExtensionContainer[testExtensions]
+	if err := c.Add(200, &uper.ConstrainedInteger{C: &uper.Constraint{Lb: 0, Ub: 255}, Value: 9}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	var buf bytes.Buffer
+	uw := uper.NewWriter(&buf)
+	if err := c.Encode(uw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := hex.EncodeToString(buf.Bytes()), "000000c8404240"; got != want {
+		t.Errorf("Encode() = %s, want %s", got, want)
+	}
+	var decoded UperProtocolExtensionContainer[testExtensions]
+	if err := decoded.Decode(uper.NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if f, ok := decoded.Get(200); !ok || f.Value.(*uper.ConstrainedInteger).Value != 9 {
+		t.Errorf("extension 200 = %+v", f)
+	}
+}
+
+func TestAperProtocolIESingleContainer(t *testing.T) {
+	c := AperProtocolIESingleContainer[testIEs]{ID: 10, Criticality: Reject, Value: &aper.ConstrainedInteger{C: &aper.Constraint{Lb: 0, Ub: 255}, Value: 7}}
+	var buf bytes.Buffer
+	aw := aper.NewWriter(&buf)
+	if err := c.Encode(aw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := hex.EncodeToString(buf.Bytes()), "000a000107"; got != want {
+		t.Errorf("Encode() = %s, want %s", got, want)
+	}
+	var decoded AperProtocolIESingleContainer[testIEs]
+	if err := decoded.Decode(aper.NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if decoded.ID != 10 || decoded.Value.(*aper.ConstrainedInteger).Value != 7 {
+		t.Errorf("Decode() = %+v", decoded)
+	}
+}
+
+func TestRegister_Twice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic registering IE 10 twice")
+		}
+	}()
+	Register[testIEs](10, Reject, func() aper.IE { return new(aper.ConstrainedInteger) })
+}
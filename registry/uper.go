@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"github.com/lvdund/asn1go/uper"
+	"github.com/lvdund/asn1go/utils"
+)
+
+var (
+	uperIeIDConstraint         = uper.Constraint{Lb: 0, Ub: 65535}
+	uperCriticalityConstraint  = uper.Constraint{Lb: 0, Ub: 2}
+	uperContainerSize          = uper.Constraint{Lb: 0, Ub: MaxProtocolIEs}
+	uperExtensionContainerSize = uper.Constraint{Lb: 1, Ub: MaxProtocolExtensions}
+)
+
+// UperProtocolIEField is an IE of a container of the set S in the unaligned
+// variant. Value is nil for an IE whose id is not registered, whose open type
+// octets are kept in Raw instead; Decode then returns an *UnknownIEError if
+// the IE is of criticality reject.
+type UperProtocolIEField[S any] struct {
+	ID          int64
+	Criticality Criticality
+	Value       uper.IE
+	Raw         []byte
+}
+
+func (f *UperProtocolIEField[S]) Encode(uw *uper.UperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("ProtocolIEField", err)
+	}()
+	if err = uw.WriteInteger(f.ID, &uperIeIDConstraint, false); err != nil {
+		return
+	}
+	if err = uw.WriteEnumerate(uint64(f.Criticality), uperCriticalityConstraint, false); err != nil {
+		return
+	}
+	if f.Value == nil {
+		err = uw.WriteOpenType(f.Raw)
+		return
+	}
+	err = uw.WriteOpenTypeValue(f.Value)
+	return
+}
+
+func (f *UperProtocolIEField[S]) Decode(ur *uper.UperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("ProtocolIEField", err)
+	}()
+	*f = UperProtocolIEField[S]{}
+	if f.ID, err = ur.ReadInteger(&uperIeIDConstraint, false); err != nil {
+		return
+	}
+	var criticality uint64
+	if criticality, err = ur.ReadEnumerate(uperCriticalityConstraint, false); err != nil {
+		return
+	}
+	f.Criticality = Criticality(criticality)
+	entry, ok := TableOf[S, uper.IE]().Lookup(f.ID)
+	if !ok {
+		if f.Raw, err = ur.ReadOpenType(); err == nil {
+			err = unknownIE(f.ID, f.Criticality)
+		}
+		return
+	}
+	f.Value = entry.New()
+	err = ur.ReadOpenTypeValue(f.Value)
+	return
+}
+
+// UperProtocolIESingleContainer is a ProtocolIE-SingleContainer of the set S,
+// a single ProtocolIE-Field, in the unaligned variant.
+type UperProtocolIESingleContainer[S any] = UperProtocolIEField[S]
+
+// UperProtocolIEContainer is a ProtocolIE-Container of the set S, SEQUENCE
+// (SIZE (0..maxProtocolIEs)) OF ProtocolIE-Field, in the unaligned variant.
+type UperProtocolIEContainer[S any] struct {
+	List []UperProtocolIEField[S]
+}
+
+// Add appends the IE id with the criticality it is registered with.
+func (c *UperProtocolIEContainer[S]) Add(id int64, value uper.IE) (err error) {
+	c.List, err = uperAddField(c.List, id, value)
+	return utils.WrapError("ProtocolIEContainer", err)
+}
+
+// Get returns the first IE with the given id.
+func (c *UperProtocolIEContainer[S]) Get(id int64) (*UperProtocolIEField[S], bool) {
+	return uperGetField(c.List, id)
+}
+
+func (c *UperProtocolIEContainer[S]) Encode(uw *uper.UperWriter) error {
+	return utils.WrapError("ProtocolIEContainer", uperEncodeFields(c.List, uw, &uperContainerSize))
+}
+
+func (c *UperProtocolIEContainer[S]) Decode(ur *uper.UperReader) (err error) {
+	c.List, err = uperDecodeFields[S](ur, &uperContainerSize)
+	return utils.WrapError("ProtocolIEContainer", err)
+}
+
+// UperProtocolExtensionField is a ProtocolExtensionField of the set S in the
+// unaligned variant. It is encoded like a ProtocolIE-Field, Value holding the
+// extensionValue.
+type UperProtocolExtensionField[S any] = UperProtocolIEField[S]
+
+// UperProtocolExtensionContainer is a ProtocolExtensionContainer of the set S,
+// SEQUENCE (SIZE (1..maxProtocolExtensions)) OF ProtocolExtensionField, in
+// the unaligned variant. Its extensions are registered in S like IEs; as the
+// container cannot be empty it is normally held by pointer, nil when absent.
+type UperProtocolExtensionContainer[S any] struct {
+	List []UperProtocolExtensionField[S]
+}
+
+// Add appends the extension id with the criticality it is registered with.
+func (c *UperProtocolExtensionContainer[S]) Add(id int64, value uper.IE) (err error) {
+	c.List, err = uperAddField(c.List, id, value)
+	return utils.WrapError("ProtocolExtensionContainer", err)
+}
+
+// Get returns the first extension with the given id.
+func (c *UperProtocolExtensionContainer[S]) Get(id int64) (*UperProtocolExtensionField[S], bool) {
+	return uperGetField(c.List, id)
+}
+
+func (c *UperProtocolExtensionContainer[S]) Encode(uw *uper.UperWriter) error {
+	return utils.WrapError("ProtocolExtensionContainer", uperEncodeFields(c.List, uw, &uperExtensionContainerSize))
+}
+
+func (c *UperProtocolExtensionContainer[S]) Decode(ur *uper.UperReader) (err error) {
+	c.List, err = uperDecodeFields[S](ur, &uperExtensionContainerSize)
+	return utils.WrapError("ProtocolExtensionContainer", err)
+}
+
+func uperAddField[S any](list []UperProtocolIEField[S], id int64, value uper.IE) ([]UperProtocolIEField[S], error) {
+	criticality, err := criticalityOf[S, uper.IE](id)
+	if err != nil {
+		return list, err
+	}
+	return append(list, UperProtocolIEField[S]{ID: id, Criticality: criticality, Value: value}), nil
+}
+
+func uperGetField[S any](list []UperProtocolIEField[S], id int64) (*UperProtocolIEField[S], bool) {
+	for i := range list {
+		if list[i].ID == id {
+			return &list[i], true
+		}
+	}
+	return nil, false
+}
+
+// uperEncodeFields writes a list of fields whose size is constrained by size.
+func uperEncodeFields[S any](list []UperProtocolIEField[S], uw *uper.UperWriter, size *uper.Constraint) error {
+	fields := make([]*UperProtocolIEField[S], len(list))
+	for i := range list {
+		fields[i] = &list[i]
+	}
+	return uper.NewListContainer(fields, size, false).Encode(uw)
+}
+
+// uperDecodeFields reads a list of fields whose size is constrained by size.
+// The IEs of criticality reject whose id is not registered do not stop the
+// decoding: the whole list is returned along with their errors.
+func uperDecodeFields[S any](ur *uper.UperReader, size *uper.Constraint) (list []UperProtocolIEField[S], err error) {
+	var unknown unknownIEs
+	decode := func(ur *uper.UperReader) (*UperProtocolIEField[S], error) {
+		f := new(UperProtocolIEField[S])
+		return f, unknown.keep(f.Decode(ur))
+	}
+	for f, err := range uper.ReadSequenceOfSeq(decode, ur, size, false) {
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	err = unknown.err()
+	return
+}
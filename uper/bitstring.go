@@ -0,0 +1,161 @@
+package uper
+
+import (
+	"strings"
+
+	"github.com/lvdund/asn1go/utils"
+)
+
+// Bit returns bit i, counting from the leading bit (bit 0). Bits beyond
+// NumBits are reported as 0.
+func (b *BitString) Bit(i uint64) bool {
+	if i >= b.NumBits || i>>3 >= uint64(len(b.Bytes)) {
+		return false
+	}
+	return b.Bytes[i>>3]&(0x80>>(i&7)) != 0
+}
+
+// SetBit sets bit i to 1, growing the string if i is beyond NumBits.
+func (b *BitString) SetBit(i uint64) {
+	b.grow(i + 1)
+	b.Bytes[i>>3] |= 0x80 >> (i & 7)
+}
+
+// ClearBit sets bit i to 0. It never grows the string.
+func (b *BitString) ClearBit(i uint64) {
+	if i < b.NumBits && i>>3 < uint64(len(b.Bytes)) {
+		b.Bytes[i>>3] &^= 0x80 >> (i & 7)
+	}
+}
+
+// grow extends the string with 0 bits up to n bits.
+func (b *BitString) grow(n uint64) {
+	if n <= b.NumBits {
+		return
+	}
+	if nBytes := (n + 7) >> 3; uint64(len(b.Bytes)) < nBytes {
+		b.Bytes = append(b.Bytes, make([]byte, nBytes-uint64(len(b.Bytes)))...)
+	}
+	for i := b.NumBits; i < n; i++ { //unused bits may hold garbage
+		b.Bytes[i>>3] &^= 0x80 >> (i & 7)
+	}
+	b.NumBits = n
+}
+
+// Uint64 returns the string as an unsigned number, the leading bit being the
+// most significant one. Only the last 64 bits are kept for longer strings.
+func (b *BitString) Uint64() (v uint64) {
+	for i := uint64(0); i < b.NumBits; i++ {
+		v <<= 1
+		if b.Bit(i) {
+			v |= 1
+		}
+	}
+	return
+}
+
+// FromUint64 sets the string to the n low-order bits of v, most significant
+// bit first. Bits above the 64th are 0.
+func (b *BitString) FromUint64(v uint64, n uint64) {
+	b.Bytes = make([]byte, (n+7)>>3)
+	b.NumBits = n
+	for i := uint64(0); i < n; i++ {
+		if shift := n - 1 - i; shift < 64 && (v>>shift)&1 == 1 {
+			b.Bytes[i>>3] |= 0x80 >> (i & 7)
+		}
+	}
+}
+
+// Slice returns a copy of bits [from, to).
+func (b *BitString) Slice(from, to uint64) (s BitString) {
+	if to > b.NumBits {
+		to = b.NumBits
+	}
+	if from >= to {
+		return
+	}
+	s.grow(to - from)
+	for i := from; i < to; i++ {
+		if b.Bit(i) {
+			s.Bytes[(i-from)>>3] |= 0x80 >> ((i - from) & 7)
+		}
+	}
+	return
+}
+
+// TrimTrailingZeros returns a copy without its trailing 0 bits, as X.691
+// clause 16.2 requires for a BIT STRING with named bits.
+func (b *BitString) TrimTrailingZeros() BitString {
+	n := b.NumBits
+	for n > 0 && !b.Bit(n-1) {
+		n--
+	}
+	return b.Slice(0, n)
+}
+
+// String returns the ASN.1 value notation of the string, e.g. '0101'B.
+func (b BitString) String() string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for i := uint64(0); i < b.NumBits; i++ {
+		if b.Bit(i) {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	sb.WriteString("'B")
+	return sb.String()
+}
+
+// Encode writes the string as a BIT STRING with the SIZE constraint pc.
+func (b *BitString) Encode(uw *UperWriter, pc *PerConstraint) (err error) {
+	content := b.Slice(0, b.NumBits) //WriteBits clears the unused bits of its input
+	err = uw.WriteBitStringWith(content.Bytes, uint(content.NumBits), pc)
+	return
+}
+
+// Decode reads a BIT STRING with the SIZE constraint pc.
+func (b *BitString) Decode(ur *UperReader, pc *PerConstraint) (err error) {
+	content, nbits, err := ur.ReadBitStringWith(pc)
+	if err != nil {
+		return
+	}
+	b.Bytes, b.NumBits = content, uint64(nbits)
+	return
+}
+
+// WriteNamedBitString encodes a BIT STRING type defined with a NamedBitList:
+// trailing 0 bits are removed and, if that leaves fewer bits than the lower
+// size bound, 0 bits are appended up to it (X.691 clause 16.2, 16.3).
+func (uw *UperWriter) WriteNamedBitString(b BitString, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteNamedBitString", err)
+	}()
+	trimmed := b.TrimTrailingZeros()
+	if pc.Category() != Unconstrained && pc.Lb > 0 {
+		trimmed.grow(uint64(pc.Lb))
+	}
+	err = uw.WriteBitStringWith(trimmed.Bytes, uint(trimmed.NumBits), pc)
+	return
+}
+
+// ReadNamedBitString decodes a BIT STRING written by WriteNamedBitString. A
+// canonical reader rejects trailing 0 bits that WriteNamedBitString would
+// have removed.
+func (ur *UperReader) ReadNamedBitString(pc *PerConstraint) (b BitString, err error) {
+	defer func() {
+		err = utils.WrapError("ReadNamedBitString", err)
+	}()
+	if err = b.Decode(ur, pc); err != nil || !ur.canonical {
+		return
+	}
+	n := b.TrimTrailingZeros().NumBits
+	if pc.Category() != Unconstrained && pc.Lb > 0 {
+		n = max(n, uint64(pc.Lb))
+	}
+	if b.NumBits != n {
+		err = ErrNonCanonical
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBitString_Methods(t *testing.T) {
+	var b BitString
+	b.FromUint64(0xabc, 12)
+	if got := b.String(); got != "'101010111100'B" {
+		t.Errorf("String() = %s", got)
+	}
+	if got := b.Uint64(); got != 0xabc {
+		t.Errorf("Uint64() = %x, want abc", got)
+	}
+	if !b.Bit(0) || b.Bit(1) || b.Bit(12) {
+		t.Errorf("Bit() mismatch on %s", b)
+	}
+	s := b.Slice(4, 8)
+	if got := s.String(); got != "'1011'B" {
+		t.Errorf("Slice(4, 8) = %s", got)
+	}
+
+	b.SetBit(15)
+	if b.NumBits != 16 || b.Uint64() != 0xabc1 {
+		t.Errorf("SetBit(15) = %s", b)
+	}
+	b.ClearBit(0)
+	if b.Uint64() != 0x2bc1 {
+		t.Errorf("ClearBit(0) = %s", b)
+	}
+	if trimmed := (&BitString{Bytes: []byte{0xa0, 0x00}, NumBits: 16}).TrimTrailingZeros(); trimmed.String() != "'101'B" {
+		t.Errorf("TrimTrailingZeros() = %s", trimmed)
+	}
+}
+
+func TestBitString_EncodeDecode(t *testing.T) {
+	var b BitString
+	b.FromUint64(0xabc, 12)
+	pc := Bounded(1, 16)
+
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	if err := b.Encode(uw, pc); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var got BitString
+	if err := got.Decode(NewReader(bytes.NewReader(buf.Bytes())), pc); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got.String() != b.String() {
+		t.Errorf("Decode() = %s, want %s", got, b)
+	}
+}
+
+func TestUperWriter_WriteNamedBitString(t *testing.T) {
+	// bits 0 and 2 of an 8-bit value: '10100000'B
+	b := BitString{Bytes: []byte{0xa0}, NumBits: 8}
+	tests := []struct {
+		name     string
+		pc       *PerConstraint
+		expected string
+	}{
+		{"unconstrained", nil, "03a0"},
+		{"SIZE(8)", Bounded(8, 8), "a0"},
+		{"SIZE(4..8)", Bounded(4, 8), "14"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			uw := NewWriter(&buf)
+			if err := uw.WriteNamedBitString(b, tt.pc); err != nil {
+				t.Fatalf("WriteNamedBitString() error = %v", err)
+			}
+			if err := uw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteNamedBitString() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+	if b.Bytes[0] != 0xa0 || b.NumBits != 8 {
+		t.Errorf("WriteNamedBitString() modified its input: %s", b)
+	}
+}
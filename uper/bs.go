@@ -120,9 +120,11 @@ func (bs *bitstreamWriter) WriteBits(content []byte, nbits uint) (err error) {
 
 /********** BITSTREAM READER (UPER - NO ALIGNMENT) ***************/
 type bitstreamReader struct {
-	r     io.Reader
-	b     [1]byte
-	index uint8 //number of read bits / index of the next bit to read [0:8]
+	r         io.Reader
+	b         [1]byte
+	index     uint8  //number of read bits / index of the next bit to read [0:8]
+	limited   bool   //whether the reader is limited to a number of bits
+	remaining uint64 //number of bits left to read when limited
 }
 
 func NewBitStreamReader(r io.Reader) *bitstreamReader {
@@ -132,7 +134,21 @@ func NewBitStreamReader(r io.Reader) *bitstreamReader {
 	}
 }
 
+// consume accounts for nbits read from a limited reader.
+func (bs *bitstreamReader) consume(nbits uint) error {
+	if bs.limited {
+		if uint64(nbits) > bs.remaining {
+			return ErrIncomplete
+		}
+		bs.remaining -= uint64(nbits)
+	}
+	return nil
+}
+
 func (bs *bitstreamReader) ReadBool() (bool, error) {
+	if err := bs.consume(1); err != nil {
+		return Zero, err
+	}
 	if bs.index == 8 {
 		if _, err := bs.r.Read(bs.b[:]); err != nil && err != io.EOF {
 			return Zero, err
@@ -153,6 +169,9 @@ func (bs *bitstreamReader) ReadBits(nbits uint) (output []byte, err error) {
 	if nbits == 0 {
 		return
 	}
+	if err = bs.consume(nbits); err != nil {
+		return
+	}
 
 	nOutputBytes := (nbits + 7) >> 3
 	output = make([]byte, nOutputBytes)
@@ -0,0 +1,95 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// CHOICE { a INTEGER (0..255), b INTEGER (0..255), ..., c INTEGER (0..65535), d INTEGER (0..65535) }
+func testChoiceAlternative(v uint64) UperUnmarshaller {
+	switch v {
+	case 1, 2:
+		return &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 255}}
+	case 3, 4:
+		return &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 65535}}
+	}
+	return nil
+}
+
+func TestUperWriter_WriteChoiceValue(t *testing.T) {
+	const uBound = 1
+	tests := []struct {
+		name     string
+		choice   uint64
+		value    *ConstrainedInteger
+		expected string
+	}{
+		{"root a", 1, &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 255}, Value: 5}, "0140"},
+		{"root b", 2, &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 255}, Value: 5}, "4140"},
+		// extension bit, addition index 0 as '0000000'B, then the open type
+		{"extension c", 3, &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 65535}, Value: 300}, "8002012c"},
+		{"extension d", 4, &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 65535}, Value: 300}, "8102012c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			uw := NewWriter(&buf)
+			if err := uw.WriteChoiceValue(tt.choice, uBound, true, tt.value); err != nil {
+				t.Fatalf("WriteChoiceValue() error = %v", err)
+			}
+			if err := uw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteChoiceValue() = %s, want %s", got, tt.expected)
+			}
+
+			var decoded *ConstrainedInteger
+			ur := NewReader(bytes.NewReader(buf.Bytes()))
+			choice, raw, err := ur.ReadChoiceValue(uBound, true, func(v uint64) UperUnmarshaller {
+				value := testChoiceAlternative(v)
+				decoded, _ = value.(*ConstrainedInteger)
+				return value
+			})
+			if err != nil {
+				t.Fatalf("ReadChoiceValue() error = %v", err)
+			}
+			if choice != tt.choice || raw != nil || decoded == nil || decoded.Value != tt.value.Value {
+				t.Errorf("ReadChoiceValue() = %d, %x, %v", choice, raw, decoded)
+			}
+		})
+	}
+
+	if err := NewWriter(new(bytes.Buffer)).WriteChoice(3, uBound, false); !errors.Is(err, ErrInextensible) {
+		t.Errorf("WriteChoice() error = %v for an extension of a non-extensible CHOICE", err)
+	}
+}
+
+func TestUperReader_ReadChoiceValue_Unknown(t *testing.T) {
+	// a newer peer sends the fifth alternative, e INTEGER (0..65535)
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	if err := uw.WriteChoiceValue(5, 1, true, &ConstrainedInteger{C: &Constraint{Lb: 0, Ub: 65535}, Value: 300}); err != nil {
+		t.Fatalf("WriteChoiceValue() error = %v", err)
+	}
+	if err := uw.WriteBool(true); err != nil {
+		t.Fatalf("WriteBool() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	ur := NewReader(bytes.NewReader(buf.Bytes()))
+	choice, raw, err := ur.ReadChoiceValue(1, true, testChoiceAlternative)
+	if err != nil {
+		t.Fatalf("ReadChoiceValue() error = %v", err)
+	}
+	if choice != 5 || !bytes.Equal(raw, []byte{0x01, 0x2c}) {
+		t.Errorf("ReadChoiceValue() = %d, %x, want 5, 012c", choice, raw)
+	}
+	if bit, err := ur.ReadBool(); err != nil || !bit {
+		t.Errorf("ReadBool() after unknown alternative = %v, %v", bit, err)
+	}
+}
@@ -0,0 +1,358 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+func TestPerConstraint_Category(t *testing.T) {
+	tests := []struct {
+		name string
+		pc   *PerConstraint
+		want ConstraintCategory
+	}{
+		{"nil", nil, Unconstrained},
+		{"MIN..MAX", Unbounded(), Unconstrained},
+		{"MIN..10", AtMost(10), Unconstrained},
+		{"0..MAX", AtLeast(0), SemiConstrained},
+		{"0..10", Bounded(0, 10), Constrained},
+		{"legacy nil", NewPerConstraint(nil, true), Unconstrained},
+		{"legacy 1..8", NewPerConstraint(&Constraint{Lb: 1, Ub: 8}, false), Constrained},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pc.Category(); got != tt.want {
+				t.Errorf("Category() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	ext := Bounded(0, 10).Extend(Constraint{Lb: 20, Ub: 30})
+	if !ext.IsExtensible() || len(ext.Additions) != 1 {
+		t.Errorf("Extend() = %+v", ext)
+	}
+	if full := Bounded(math.MinInt64, math.MaxInt64); full.Range() != 0 || full.Span() != math.MaxUint64 {
+		t.Errorf("full int64 range: Range() = %d, Span() = %d", full.Range(), full.Span())
+	}
+}
+
+func TestUperWriter_WriteIntegerWith(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int64
+		pc       *PerConstraint
+		expected string
+	}{
+		{"0..MAX value 0", 0, AtLeast(0), "0100"},
+		{"0..MAX value 5", 5, AtLeast(0), "0105"},
+		{"0..MAX value 256", 256, AtLeast(0), "020100"},
+		{"-5..MAX value -5", -5, AtLeast(-5), "0100"},
+		{"MIN..10 value -1", -1, AtMost(10), "01ff"},
+		{"MIN..10 value -129", -129, AtMost(10), "02ff7f"},
+		{"MIN..MAX value 128", 128, Unbounded(), "020080"},
+		{"0..65535 extensible", 300, Bounded(0, 65535).Extend(), "009600"},
+		{"0..65536 value 65536", 65536, Bounded(0, 65536), "800000"},
+		{"0..2^32-1 value 0", 0, Bounded(0, math.MaxUint32), "00000000"},
+		{"0..2^32-1 value max", math.MaxUint32, Bounded(0, math.MaxUint32), "ffffffff"},
+		{"0..2^40 value 1", 1, Bounded(0, 1<<40), "000000000080"},
+		{"MIN..MAX bounded value MIN", math.MinInt64, Bounded(math.MinInt64, math.MaxInt64), "0000000000000000"},
+		{"MIN..MAX bounded value MAX", math.MaxInt64, Bounded(math.MinInt64, math.MaxInt64), "ffffffffffffffff"},
+		{"MIN..MAX semi value MAX", math.MaxInt64, AtLeast(math.MinInt64), "08ffffffffffffffff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			uw := NewWriter(&buf)
+			if err := uw.WriteIntegerWith(tt.value, tt.pc); err != nil {
+				t.Fatalf("WriteIntegerWith() error = %v", err)
+			}
+			if err := uw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteIntegerWith() = %s, want %s", got, tt.expected)
+			}
+
+			ur := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ur.ReadIntegerWith(tt.pc)
+			if err != nil {
+				t.Fatalf("ReadIntegerWith() error = %v", err)
+			}
+			if value != tt.value {
+				t.Errorf("ReadIntegerWith() = %d, want %d", value, tt.value)
+			}
+		})
+	}
+}
+
+// lb, lb+1, ub-1 and ub of ranges whose bit-field grows by an octet at a
+// time, then the int64 extremes of each category
+func TestUperWriter_WriteIntegerWith_Boundaries(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int64
+		pc       *PerConstraint
+		expected string
+	}{
+		{"0..256 lb", 0, Bounded(0, 256), "0000"},
+		{"0..256 lb+1", 1, Bounded(0, 256), "0080"},
+		{"0..256 ub-1", 255, Bounded(0, 256), "7f80"},
+		{"0..256 ub", 256, Bounded(0, 256), "8000"},
+		{"0..1000 lb", 0, Bounded(0, 1000), "0000"},
+		{"0..1000 lb+1", 1, Bounded(0, 1000), "0040"},
+		{"0..1000 ub-1", 999, Bounded(0, 1000), "f9c0"},
+		{"0..1000 ub", 1000, Bounded(0, 1000), "fa00"},
+		{"0..65535 lb", 0, Bounded(0, 65535), "0000"},
+		{"0..65535 lb+1", 1, Bounded(0, 65535), "0001"},
+		{"0..65535 ub-1", 65534, Bounded(0, 65535), "fffe"},
+		{"0..65535 ub", 65535, Bounded(0, 65535), "ffff"},
+		{"0..65536 lb", 0, Bounded(0, 65536), "000000"},
+		{"0..65536 lb+1", 1, Bounded(0, 65536), "000080"},
+		{"0..65536 ub-1", 65535, Bounded(0, 65536), "7fff80"},
+		{"0..65536 ub", 65536, Bounded(0, 65536), "800000"},
+		{"2^8 lb", -128, Bounded(-128, 127), "00"},
+		{"2^8 lb+1", -127, Bounded(-128, 127), "01"},
+		{"2^8 ub-1", 126, Bounded(-128, 127), "fe"},
+		{"2^8 ub", 127, Bounded(-128, 127), "ff"},
+		{"2^16 lb", 0, Bounded(0, 65535), "0000"},
+		{"2^16 lb+1", 1, Bounded(0, 65535), "0001"},
+		{"2^16 ub-1", 65534, Bounded(0, 65535), "fffe"},
+		{"2^16 ub", 65535, Bounded(0, 65535), "ffff"},
+		{"2^24 lb", 1, Bounded(1, 1<<24), "000000"},
+		{"2^24 lb+1", 2, Bounded(1, 1<<24), "000001"},
+		{"2^24 ub-1", 1<<24 - 1, Bounded(1, 1<<24), "fffffe"},
+		{"2^24 ub", 1 << 24, Bounded(1, 1<<24), "ffffff"},
+		{"2^32 lb", 0, Bounded(0, math.MaxUint32), "00000000"},
+		{"2^32 lb+1", 1, Bounded(0, math.MaxUint32), "00000001"},
+		{"2^32 ub-1", math.MaxUint32 - 1, Bounded(0, math.MaxUint32), "fffffffe"},
+		{"2^32 ub", math.MaxUint32, Bounded(0, math.MaxUint32), "ffffffff"},
+		{"2^40 lb", -1 << 39, Bounded(-1<<39, 1<<39-1), "0000000000"},
+		{"2^40 lb+1", -1<<39 + 1, Bounded(-1<<39, 1<<39-1), "0000000001"},
+		{"2^40 ub-1", 1<<39 - 2, Bounded(-1<<39, 1<<39-1), "fffffffffe"},
+		{"2^40 ub", 1<<39 - 1, Bounded(-1<<39, 1<<39-1), "ffffffffff"},
+		{"2^48 lb", 0, Bounded(0, 1<<48-1), "000000000000"},
+		{"2^48 lb+1", 1, Bounded(0, 1<<48-1), "000000000001"},
+		{"2^48 ub-1", 1<<48 - 2, Bounded(0, 1<<48-1), "fffffffffffe"},
+		{"2^48 ub", 1<<48 - 1, Bounded(0, 1<<48-1), "ffffffffffff"},
+		{"2^56 lb", 0, Bounded(0, 1<<56-1), "00000000000000"},
+		{"2^56 lb+1", 1, Bounded(0, 1<<56-1), "00000000000001"},
+		{"2^56 ub-1", 1<<56 - 2, Bounded(0, 1<<56-1), "fffffffffffffe"},
+		{"2^56 ub", 1<<56 - 1, Bounded(0, 1<<56-1), "ffffffffffffff"},
+		// a range of 2^64 values, the int64 image of 0..2^64-1
+		{"2^64 lb", math.MinInt64, Bounded(math.MinInt64, math.MaxInt64), "0000000000000000"},
+		{"2^64 lb+1", math.MinInt64 + 1, Bounded(math.MinInt64, math.MaxInt64), "0000000000000001"},
+		{"2^64 ub-1", math.MaxInt64 - 1, Bounded(math.MinInt64, math.MaxInt64), "fffffffffffffffe"},
+		{"2^64 ub", math.MaxInt64, Bounded(math.MinInt64, math.MaxInt64), "ffffffffffffffff"},
+
+		{"semi 0..MAX value 255", 255, AtLeast(0), "01ff"},
+		{"semi 0..MAX value 256", 256, AtLeast(0), "020100"},
+		{"semi 0..MAX value MAX", math.MaxInt64, AtLeast(0), "087fffffffffffffff"},
+		{"semi MIN..MAX value MIN", math.MinInt64, AtLeast(math.MinInt64), "0100"},
+		{"semi MIN..MAX value MIN+1", math.MinInt64 + 1, AtLeast(math.MinInt64), "0101"},
+		{"semi MIN..MAX value MAX-1", math.MaxInt64 - 1, AtLeast(math.MinInt64), "08fffffffffffffffe"},
+		{"semi MIN..MAX value MAX", math.MaxInt64, AtLeast(math.MinInt64), "08ffffffffffffffff"},
+
+		{"unconstrained 127", 127, Unbounded(), "017f"},
+		{"unconstrained 128", 128, Unbounded(), "020080"},
+		{"unconstrained -128", -128, Unbounded(), "0180"},
+		{"unconstrained -129", -129, Unbounded(), "02ff7f"},
+		{"unconstrained MIN", math.MinInt64, Unbounded(), "088000000000000000"},
+		{"unconstrained MIN+1", math.MinInt64 + 1, Unbounded(), "088000000000000001"},
+		{"unconstrained MAX-1", math.MaxInt64 - 1, Unbounded(), "087ffffffffffffffe"},
+		{"unconstrained MAX", math.MaxInt64, Unbounded(), "087fffffffffffffff"},
+		{"MIN..MAX value MIN", math.MinInt64, AtMost(math.MaxInt64), "088000000000000000"},
+		{"0..10,... extension MIN", math.MinInt64, Bounded(0, 10).Extend(), "84400000000000000000"},
+		{"0..10,... extension MAX", math.MaxInt64, Bounded(0, 10).Extend(), "843fffffffffffffff80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			uw := NewWriter(&buf)
+			if err := uw.WriteIntegerWith(tt.value, tt.pc); err != nil {
+				t.Fatalf("WriteIntegerWith() error = %v", err)
+			}
+			if err := uw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteIntegerWith() = %s, want %s", got, tt.expected)
+			}
+
+			ur := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ur.ReadIntegerWith(tt.pc)
+			if err != nil {
+				t.Fatalf("ReadIntegerWith() error = %v", err)
+			}
+			if value != tt.value {
+				t.Errorf("ReadIntegerWith() = %d, want %d", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestUperWriter_WriteIntegerWith_OutOfRoot(t *testing.T) {
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	if err := uw.WriteIntegerWith(11, Bounded(0, 10)); err == nil {
+		t.Error("expected an error for a value outside a non-extensible constraint")
+	}
+	if err := uw.WriteIntegerWith(-1, AtLeast(0)); err == nil {
+		t.Error("expected an error for a value below a semi-constrained lower bound")
+	}
+	if err := uw.WriteIntegerWith(0, Bounded(10, 0)); err == nil {
+		t.Error("expected an error for an inverted constraint")
+	}
+}
+
+func TestUperWriter_WriteOctetStringWith(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    []byte
+		pc       *PerConstraint
+		expected string
+	}{
+		{"SIZE(1..MAX)", []byte("abc"), AtLeast(1), "03616263"},
+		{"SIZE(MIN..4)", []byte("ab"), AtMost(4), "4c2c40"},
+		{"SIZE(3) fixed", []byte("abc"), Bounded(3, 3), "616263"},
+		{"SIZE(1..4, ...) in root", []byte("ab"), Bounded(1, 4).Extend(), "2c2c40"},
+		{"SIZE(1..4, ...) extension", []byte("abcde"), Bounded(1, 4).Extend(), "82b0b131b23280"},
+		{"SIZE(0..1000)", []byte("ab"), Bounded(0, 1000), "00985880"},
+		{"SIZE(0..70000)", []byte("ab"), Bounded(0, 70000), "026162"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			uw := NewWriter(&buf)
+			if err := uw.WriteOctetStringWith(tt.value, tt.pc); err != nil {
+				t.Fatalf("WriteOctetStringWith() error = %v", err)
+			}
+			if err := uw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteOctetStringWith() = %s, want %s", got, tt.expected)
+			}
+
+			ur := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ur.ReadOctetStringWith(tt.pc)
+			if err != nil {
+				t.Fatalf("ReadOctetStringWith() error = %v", err)
+			}
+			if !bytes.Equal(value, tt.value) {
+				t.Errorf("ReadOctetStringWith() = %x, want %x", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestWriteSequenceOfWith_SizeWidth(t *testing.T) {
+	// the count of SIZE(0..1000) is a 10-bit field
+	items := []*testInteger{{1}, {2}, {3}}
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	if err := WriteSequenceOfWith(items, uw, Bounded(0, 1000)); err != nil {
+		t.Fatalf("WriteSequenceOfWith() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := hex.EncodeToString(buf.Bytes()); got != "00c04080c0" {
+		t.Errorf("WriteSequenceOfWith() = %s, want 00c04080c0", got)
+	}
+}
+
+func TestWriteSequenceOfWith(t *testing.T) {
+	items := []*testInteger{{1}, {2}, {3}}
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	if err := WriteSequenceOfWith(items, uw, Bounded(1, 8)); err != nil {
+		t.Fatalf("WriteSequenceOfWith() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	ur := NewReader(bytes.NewReader(buf.Bytes()))
+	got, err := ReadSequenceOfExWith(func() *testInteger { return new(testInteger) }, ur, Bounded(1, 8))
+	if err != nil {
+		t.Fatalf("ReadSequenceOfExWith() error = %v", err)
+	}
+	if len(got) != len(items) {
+		t.Fatalf("ReadSequenceOfExWith() returned %d items, want %d", len(got), len(items))
+	}
+	for i := range got {
+		if got[i].Value != items[i].Value {
+			t.Errorf("item %d = %d, want %d", i, got[i].Value, items[i].Value)
+		}
+	}
+}
+
+// testInteger is an INTEGER (0..255) list element
+type testInteger struct {
+	Value int64
+}
+
+func (ti *testInteger) Encode(uw *UperWriter) error {
+	return uw.WriteIntegerWith(ti.Value, Bounded(0, 255))
+}
+
+func (ti *testInteger) Decode(ur *UperReader) (err error) {
+	ti.Value, err = ur.ReadIntegerWith(Bounded(0, 255))
+	return
+}
+
+// NGAP-style INTEGER (0..65535, ...): values outside the root set the
+// extension bit and are encoded as unconstrained whole numbers
+func TestUperWriter_WriteInteger_Extension(t *testing.T) {
+	c := &Constraint{Lb: 0, Ub: 65535}
+	tests := []struct {
+		name     string
+		value    int64
+		expected string
+	}{
+		{"root lower bound", 0, "000000"},
+		{"root value", 100, "003200"},
+		{"root upper bound", 65535, "7fff80"},
+		{"extension above root", 65536, "8180800000"},
+		{"extension negative", -1, "80ff80"},
+		{"extension large", 1 << 40, "8300800000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			writer := NewWriter(buf)
+			if err := writer.WriteInteger(tt.value, c, true); err != nil {
+				t.Fatalf("WriteInteger() error = %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteInteger() = %s, want %s", got, tt.expected)
+			}
+
+			reader := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := reader.ReadInteger(c, true)
+			if err != nil {
+				t.Fatalf("ReadInteger() error = %v", err)
+			}
+			if value != tt.value {
+				t.Errorf("ReadInteger() = %d, want %d", value, tt.value)
+			}
+		})
+	}
+
+	writer := NewWriter(new(bytes.Buffer))
+	if err := writer.WriteInteger(65536, c, false); err == nil {
+		t.Error("expected an error for a value outside a non-extensible constraint")
+	}
+	additions := Bounded(0, 10).Extend(Constraint{Lb: 20, Ub: 30})
+	if err := writer.WriteIntegerWith(25, additions); err != nil {
+		t.Errorf("WriteIntegerWith() error = %v for a value in an extension addition", err)
+	}
+	if err := writer.WriteIntegerWith(15, additions); err == nil {
+		t.Error("expected an error for a value outside the root and the extension additions")
+	}
+}
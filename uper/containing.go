@@ -0,0 +1,179 @@
+package uper
+
+import (
+	"bytes"
+
+	"github.com/lvdund/asn1go/utils"
+)
+
+// Marshal returns the complete encoding of v: the encoding padded to a whole
+// number of octets, or a single zero octet if it is empty (X.691 clause 11.1).
+func Marshal[T UperMarshaller](v T) ([]byte, error) {
+	return marshal(v, false)
+}
+
+// MarshalCanonical returns the complete CANONICAL-PER encoding of v.
+func MarshalCanonical[T UperMarshaller](v T) ([]byte, error) {
+	return marshal(v, true)
+}
+
+func marshal(v UperMarshaller, canonical bool) (b []byte, err error) {
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	uw.canonical = canonical
+	if err = v.Encode(uw); err != nil {
+		return
+	}
+	if err = uw.Close(); err != nil {
+		return
+	}
+	b = buf.Bytes() //Close writes a single zero octet for an empty encoding
+	return
+}
+
+// Unmarshal decodes v from its complete encoding b.
+func Unmarshal[T UperUnmarshaller](b []byte, v T) error {
+	return unmarshal(b, v, false)
+}
+
+// UnmarshalCanonical decodes v from its complete encoding b, which must be
+// the CANONICAL-PER encoding of v.
+func UnmarshalCanonical[T UperUnmarshaller](b []byte, v T) error {
+	return unmarshal(b, v, true)
+}
+
+func unmarshal(b []byte, v UperUnmarshaller, canonical bool) error {
+	ur := NewReader(bytes.NewReader(b))
+	ur.canonical = canonical
+	return v.Decode(ur)
+}
+
+// WriteContaining encodes an OCTET STRING (CONTAINING T) whose size is
+// constrained by c: v is encoded on its own with the unaligned variant and its
+// complete encoding is written as the octet string.
+func WriteContaining[T UperMarshaller](v T, uw *UperWriter, c *Constraint, e bool) (err error) {
+	return WriteContainingWith(v, uw, NewPerConstraint(c, e))
+}
+
+// WriteContainingWith encodes an OCTET STRING (CONTAINING T) whose size is
+// constrained by pc. v is encoded in the mode of uw.
+func WriteContainingWith[T UperMarshaller](v T, uw *UperWriter, pc *PerConstraint) (err error) {
+	return WriteContainingFunc(v, func(v T) ([]byte, error) { return marshal(v, uw.canonical) }, uw, pc)
+}
+
+// WriteContainingFunc encodes an OCTET STRING (CONTAINING T) whose contained
+// value is encoded by marshal, such as aper.Marshal for an aligned value
+// inside an unaligned PDU.
+func WriteContainingFunc[T any](v T, marshal func(T) ([]byte, error), uw *UperWriter, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteContaining", err)
+	}()
+	var content []byte
+	if content, err = marshal(v); err != nil {
+		return
+	}
+	err = uw.WriteOctetStringWith(content, pc)
+	return
+}
+
+// ReadContaining decodes an OCTET STRING (CONTAINING T) written by
+// WriteContaining with the same constraint into v.
+func ReadContaining[T UperUnmarshaller](v T, ur *UperReader, c *Constraint, e bool) (err error) {
+	return ReadContainingWith(v, ur, NewPerConstraint(c, e))
+}
+
+// ReadContainingWith decodes an OCTET STRING (CONTAINING T) whose size is
+// constrained by pc into v. v is decoded in the mode of ur.
+func ReadContainingWith[T UperUnmarshaller](v T, ur *UperReader, pc *PerConstraint) (err error) {
+	return ReadContainingFunc(v, func(b []byte, v T) error { return unmarshal(b, v, ur.canonical) }, ur, pc)
+}
+
+// ReadContainingFunc decodes an OCTET STRING (CONTAINING T) whose contained
+// value is decoded by unmarshal, such as aper.Unmarshal.
+func ReadContainingFunc[T any](v T, unmarshal func([]byte, T) error, ur *UperReader, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("ReadContaining", err)
+	}()
+	var content []byte
+	if content, err = ur.ReadOctetStringWith(pc); err != nil {
+		return
+	}
+	err = unmarshal(content, v)
+	return
+}
+
+// EncodeBits encodes v on its own and returns the encoding with its exact
+// length in bits, without the padding of a complete encoding.
+func EncodeBits[T UperMarshaller](v T) (data []byte, nbits uint, err error) {
+	return encodeBits(v, false)
+}
+
+func encodeBits(v UperMarshaller, canonical bool) (data []byte, nbits uint, err error) {
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	uw.canonical = canonical
+	if err = v.Encode(uw); err != nil {
+		return
+	}
+	nbits = uint(8*buf.Len()) + uint(uw.index)
+	if err = uw.flush(); err != nil {
+		return
+	}
+	data = buf.Bytes()
+	return
+}
+
+// NewReaderBits returns a reader of the first nbits bits of data. Reading
+// past them fails with ErrIncomplete.
+func NewReaderBits(data []byte, nbits uint) *UperReader {
+	ur := NewReader(bytes.NewReader(data))
+	ur.limited = true
+	ur.remaining = min(uint64(nbits), uint64(8*len(data)))
+	return ur
+}
+
+// WriteBitStringContaining encodes a BIT STRING (CONTAINING T) whose size is
+// constrained by c: the bit string holds the encoding of v with its exact
+// length in bits.
+func WriteBitStringContaining[T UperMarshaller](v T, uw *UperWriter, c *Constraint, e bool) (err error) {
+	return WriteBitStringContainingWith(v, uw, NewPerConstraint(c, e))
+}
+
+// WriteBitStringContainingWith encodes a BIT STRING (CONTAINING T) whose size
+// is constrained by pc.
+func WriteBitStringContainingWith[T UperMarshaller](v T, uw *UperWriter, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteBitStringContaining", err)
+	}()
+	var content []byte
+	var nbits uint
+	if content, nbits, err = encodeBits(v, uw.canonical); err != nil {
+		return
+	}
+	err = uw.WriteBitStringWith(content, nbits, pc)
+	return
+}
+
+// ReadBitStringContaining decodes a BIT STRING (CONTAINING T) written by
+// WriteBitStringContaining with the same constraint into v. Decoding v may
+// not read past the bits of the string.
+func ReadBitStringContaining[T UperUnmarshaller](v T, ur *UperReader, c *Constraint, e bool) (err error) {
+	return ReadBitStringContainingWith(v, ur, NewPerConstraint(c, e))
+}
+
+// ReadBitStringContainingWith decodes a BIT STRING (CONTAINING T) whose size
+// is constrained by pc into v.
+func ReadBitStringContainingWith[T UperUnmarshaller](v T, ur *UperReader, pc *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("ReadBitStringContaining", err)
+	}()
+	var content []byte
+	var nbits uint
+	if content, nbits, err = ur.ReadBitStringWith(pc); err != nil {
+		return
+	}
+	r := NewReaderBits(content, nbits)
+	r.canonical = ur.canonical
+	err = v.Decode(r)
+	return
+}
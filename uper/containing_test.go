@@ -0,0 +1,151 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/lvdund/asn1go/aper"
+)
+
+var pairValue = &Constraint{Lb: 0, Ub: 255}
+
+// testPair is SEQUENCE { flag BOOLEAN, value INTEGER (0..255) }
+type testPair struct {
+	Flag  bool
+	Value int64
+}
+
+func (p *testPair) Encode(uw *UperWriter) (err error) {
+	if err = uw.WriteBool(p.Flag); err != nil {
+		return
+	}
+	return uw.WriteInteger(p.Value, pairValue, false)
+}
+
+func (p *testPair) Decode(ur *UperReader) (err error) {
+	if p.Flag, err = ur.ReadBool(); err != nil {
+		return
+	}
+	p.Value, err = ur.ReadInteger(pairValue, false)
+	return
+}
+
+// alignedPair is testPair encoded with the aligned variant
+type alignedPair testPair
+
+func (p *alignedPair) Encode(aw *aper.AperWriter) (err error) {
+	if err = aw.WriteBool(p.Flag); err != nil {
+		return
+	}
+	return aw.WriteInteger(p.Value, &aper.Constraint{Lb: 0, Ub: 255}, false)
+}
+
+func (p *alignedPair) Decode(ar *aper.AperReader) (err error) {
+	if p.Flag, err = ar.ReadBool(); err != nil {
+		return
+	}
+	p.Value, err = ar.ReadInteger(&aper.Constraint{Lb: 0, Ub: 255}, false)
+	return
+}
+
+func TestWriteContaining(t *testing.T) {
+	got := encodeTest(t, func(uw *UperWriter) error {
+		return WriteContaining(&testPair{Flag: true, Value: 5}, uw, nil, false)
+	})
+	if want := "028280"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteContaining() = %x, want %s", got, want)
+	}
+	var v testPair
+	if err := ReadContaining(&v, NewReader(bytes.NewReader(got)), nil, false); err != nil {
+		t.Fatalf("ReadContaining() error = %v", err)
+	}
+	if v != (testPair{Flag: true, Value: 5}) {
+		t.Errorf("ReadContaining() = %+v", v)
+	}
+
+	// an empty inner encoding is carried as a single zero octet
+	got = encodeTest(t, func(uw *UperWriter) error {
+		return WriteContaining(&ConstrainedInteger{C: &Constraint{Lb: 3, Ub: 3}, Value: 3}, uw, nil, false)
+	})
+	if want := "0100"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteContaining(empty) = %x, want %s", got, want)
+	}
+
+	err := WriteContaining(&testPair{}, NewWriter(new(bytes.Buffer)), &Constraint{Lb: 0, Ub: 1}, false)
+	if err == nil {
+		t.Error("expected an error for a contained value exceeding the SIZE constraint")
+	}
+}
+
+func TestWriteContainingFunc_Aligned(t *testing.T) {
+	// a leading bit shows the octet string is not aligned in the outer PDU
+	got := encodeTest(t, func(uw *UperWriter) error {
+		if err := uw.WriteBool(true); err != nil {
+			return err
+		}
+		return WriteContainingFunc(&alignedPair{Flag: true, Value: 5}, aper.Marshal[*alignedPair], uw, nil)
+	})
+	if want := "81400280"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteContainingFunc() = %x, want %s", got, want)
+	}
+	ur := NewReader(bytes.NewReader(got))
+	if _, err := ur.ReadBool(); err != nil {
+		t.Fatalf("ReadBool() error = %v", err)
+	}
+	var v alignedPair
+	if err := ReadContainingFunc(&v, aper.Unmarshal[*alignedPair], ur, nil); err != nil {
+		t.Fatalf("ReadContainingFunc() error = %v", err)
+	}
+	if v != (alignedPair{Flag: true, Value: 5}) {
+		t.Errorf("ReadContainingFunc() = %+v", v)
+	}
+}
+
+func TestEncodeBits(t *testing.T) {
+	data, nbits, err := EncodeBits(&testPair{Flag: true, Value: 5})
+	if err != nil {
+		t.Fatalf("EncodeBits() error = %v", err)
+	}
+	if hex.EncodeToString(data) != "8280" || nbits != 9 {
+		t.Errorf("EncodeBits() = %x, %d, want 8280, 9", data, nbits)
+	}
+
+	var v testPair
+	if err := v.Decode(NewReaderBits(data, nbits)); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if err := new(testPair).Decode(NewReaderBits(data, nbits-4)); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("Decode() of %d bits error = %v, want ErrIncomplete", nbits-4, err)
+	}
+}
+
+func TestWriteBitStringContaining(t *testing.T) {
+	got := encodeTest(t, func(uw *UperWriter) error {
+		return WriteBitStringContaining(&testPair{Flag: true, Value: 5}, uw, nil, false)
+	})
+	if want := "098280"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteBitStringContaining() = %x, want %s", got, want)
+	}
+	var v testPair
+	if err := ReadBitStringContaining(&v, NewReader(bytes.NewReader(got)), nil, false); err != nil {
+		t.Fatalf("ReadBitStringContaining() error = %v", err)
+	}
+	if v != (testPair{Flag: true, Value: 5}) {
+		t.Errorf("ReadBitStringContaining() = %+v", v)
+	}
+
+	// a string one bit too short for the contained value
+	short := encodeTest(t, func(uw *UperWriter) error {
+		data, nbits, err := EncodeBits(&testPair{Flag: true, Value: 5})
+		if err != nil {
+			return err
+		}
+		return uw.WriteBitString(data, nbits-1, nil, false)
+	})
+	err := ReadBitStringContaining(new(testPair), NewReader(bytes.NewReader(short)), nil, false)
+	if !errors.Is(err, ErrIncomplete) {
+		t.Errorf("ReadBitStringContaining() error = %v, want ErrIncomplete", err)
+	}
+}
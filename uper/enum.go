@@ -0,0 +1,158 @@
+package uper
+
+import (
+	"sort"
+
+	"github.com/lvdund/asn1go/utils"
+)
+
+// EnumerationDescriptor maps the named numbers of an ENUMERATED type to the
+// indexes PER encodes. Root values are numbered in ascending order of their
+// values, extension additions in the order they are defined. For
+//
+//	{ a(0), b(5), c(10), ..., d(3) }
+//
+// b has root index 1 and d has extension index 0.
+type EnumerationDescriptor struct {
+	root       []int64 //sorted root values
+	additions  []int64 //extension additions in definition order
+	extensible bool
+	rootIndex  map[int64]uint64
+	extIndex   map[int64]uint64
+}
+
+// NewEnumerationDescriptor builds a descriptor from the root named numbers (in
+// any order), whether the type has an extension marker, and the extension
+// additions in definition order.
+func NewEnumerationDescriptor(root []int64, extensible bool, additions ...int64) *EnumerationDescriptor {
+	d := &EnumerationDescriptor{
+		root:       append([]int64(nil), root...),
+		additions:  append([]int64(nil), additions...),
+		extensible: extensible || len(additions) > 0,
+		rootIndex:  make(map[int64]uint64, len(root)),
+		extIndex:   make(map[int64]uint64, len(additions)),
+	}
+	sort.Slice(d.root, func(i, j int) bool { return d.root[i] < d.root[j] })
+	for i, v := range d.root {
+		d.rootIndex[v] = uint64(i)
+	}
+	for i, v := range d.additions {
+		d.extIndex[v] = uint64(i)
+	}
+	return d
+}
+
+// Extensible reports whether the type has an extension marker.
+func (d *EnumerationDescriptor) Extensible() bool {
+	return d.extensible
+}
+
+// RootCount returns the number of values in the enumeration root.
+func (d *EnumerationDescriptor) RootCount() int {
+	return len(d.root)
+}
+
+// Index returns the PER index of v and whether it is an extension addition.
+func (d *EnumerationDescriptor) Index(v int64) (idx uint64, ext bool, ok bool) {
+	if idx, ok = d.rootIndex[v]; ok {
+		return
+	}
+	idx, ok = d.extIndex[v]
+	ext = ok
+	return
+}
+
+// Value returns the named number at a root or extension index.
+func (d *EnumerationDescriptor) Value(idx uint64, ext bool) (v int64, ok bool) {
+	list := d.root
+	if ext {
+		list = d.additions
+	}
+	if idx >= uint64(len(list)) {
+		return
+	}
+	return list[idx], true
+}
+
+// WriteNamedEnum encodes the named number v of the ENUMERATED type described
+// by d.
+func (uw *UperWriter) WriteNamedEnum(v int64, d *EnumerationDescriptor) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteNamedEnum", err)
+	}()
+
+	idx, ext, ok := d.Index(v)
+	if !ok {
+		err = ErrUnknownValue
+		return
+	}
+	err = uw.WriteNamedEnumIndex(idx, ext, d)
+	return
+}
+
+// WriteNamedEnumIndex encodes a root or extension index of the ENUMERATED
+// type described by d. The index need not be known to d if it is an
+// extension, so that the Index of an *UnknownExtensionError returned by
+// ReadNamedEnum can be written back unchanged.
+func (uw *UperWriter) WriteNamedEnumIndex(idx uint64, ext bool, d *EnumerationDescriptor) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteNamedEnumIndex", err)
+	}()
+	if ext && !d.extensible {
+		err = ErrInextensible
+		return
+	}
+	if !ext && idx >= uint64(len(d.root)) {
+		err = ErrUnknownValue
+		return
+	}
+	if d.extensible {
+		if err = uw.WriteBool(ext); err != nil {
+			return
+		}
+	}
+	if ext {
+		err = uw.writeNormallySmallNonNegativeValue(idx)
+		return
+	}
+	if n := uint64(len(d.root)); n > 1 {
+		err = uw.writeConstraintValue(n, idx)
+	}
+	return
+}
+
+// ReadNamedEnum decodes a value of the ENUMERATED type described by d. An
+// extension addition that d does not know is reported as an
+// *UnknownExtensionError carrying its index, so that a receiver can tolerate
+// values from a newer peer.
+func (ur *UperReader) ReadNamedEnum(d *EnumerationDescriptor) (v int64, err error) {
+	defer func() {
+		err = utils.WrapError("ReadNamedEnum", err)
+	}()
+
+	var ext bool
+	if d.extensible {
+		if ext, err = ur.ReadBool(); err != nil {
+			return
+		}
+	}
+	var idx uint64
+	if ext {
+		if idx, err = ur.readNormallySmallNonNegativeValue(); err != nil {
+			return
+		}
+	} else if n := uint64(len(d.root)); n > 1 {
+		if idx, err = ur.readConstraintValue(n); err != nil {
+			return
+		}
+	}
+	var ok bool
+	if v, ok = d.Value(idx, ext); !ok {
+		if ext {
+			err = &UnknownExtensionError{Index: idx}
+		} else {
+			err = ErrUnknownValue
+		}
+	}
+	return
+}
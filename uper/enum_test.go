@@ -0,0 +1,107 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestUperWriter_WriteNamedEnum(t *testing.T) {
+	// { a(0), b(5), c(10), ..., d(3) } declared out of order
+	d := NewEnumerationDescriptor([]int64{10, 0, 5}, true, 3)
+	tests := []struct {
+		name     string
+		value    int64
+		expected string
+	}{
+		{"a", 0, "00"},
+		{"b", 5, "20"},
+		{"c", 10, "40"},
+		{"d (extension)", 3, "80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			uw := NewWriter(&buf)
+			if err := uw.WriteNamedEnum(tt.value, d); err != nil {
+				t.Fatalf("WriteNamedEnum() error = %v", err)
+			}
+			if err := uw.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+				t.Errorf("WriteNamedEnum() = %s, want %s", got, tt.expected)
+			}
+
+			ur := NewReader(bytes.NewReader(buf.Bytes()))
+			value, err := ur.ReadNamedEnum(d)
+			if err != nil {
+				t.Fatalf("ReadNamedEnum() error = %v", err)
+			}
+			if value != tt.value {
+				t.Errorf("ReadNamedEnum() = %d, want %d", value, tt.value)
+			}
+		})
+	}
+
+	if err := NewWriter(new(bytes.Buffer)).WriteNamedEnum(7, d); !errors.Is(err, ErrUnknownValue) {
+		t.Errorf("WriteNamedEnum(7) error = %v, want ErrUnknownValue", err)
+	}
+	if err := NewWriter(new(bytes.Buffer)).WriteNamedEnum(3, NewEnumerationDescriptor([]int64{0, 5}, false)); !errors.Is(err, ErrUnknownValue) {
+		t.Errorf("WriteNamedEnum() of an addition on a non-extensible type error = %v, want ErrUnknownValue", err)
+	}
+}
+
+func TestUperReader_ReadNamedEnum_UnknownExtension(t *testing.T) {
+	// a newer peer knows { a(0), b(5), c(10), ..., d(3), e(4) }
+	newer := NewEnumerationDescriptor([]int64{0, 5, 10}, true, 3, 4)
+	older := NewEnumerationDescriptor([]int64{0, 5, 10}, true, 3)
+
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	if err := uw.WriteNamedEnum(4, newer); err != nil {
+		t.Fatalf("WriteNamedEnum() error = %v", err)
+	}
+	if err := uw.WriteBool(true); err != nil {
+		t.Fatalf("WriteBool() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	ur := NewReader(bytes.NewReader(buf.Bytes()))
+	_, err := ur.ReadNamedEnum(older)
+	var unknown *UnknownExtensionError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("ReadNamedEnum() error = %v, want *UnknownExtensionError", err)
+	}
+	if unknown.Index != 1 {
+		t.Errorf("UnknownExtensionError.Index = %d, want 1", unknown.Index)
+	}
+	// the unknown value has been consumed, the next field can still be read
+	if bit, err := ur.ReadBool(); err != nil || !bit {
+		t.Errorf("ReadBool() after unknown extension = %v, %v", bit, err)
+	}
+}
+
+// a root of 300 values takes 9 bits, without the octet alignment of aper
+func TestUperWriter_WriteEnumerate_Unaligned(t *testing.T) {
+	c := Constraint{Lb: 0, Ub: 299}
+	got := encodeTest(t, func(uw *UperWriter) error {
+		if err := uw.WriteBool(true); err != nil {
+			return err
+		}
+		return uw.WriteEnumerate(299, c, false)
+	})
+	if hex.EncodeToString(got) != "cac0" {
+		t.Errorf("WriteEnumerate() = %x, want cac0", got)
+	}
+	ur := NewReader(bytes.NewReader(got))
+	if _, err := ur.ReadBool(); err != nil {
+		t.Fatalf("ReadBool() error = %v", err)
+	}
+	if v, err := ur.ReadEnumerate(c, false); err != nil || v != 299 {
+		t.Errorf("ReadEnumerate() = %d, %v, want 299", v, err)
+	}
+}
@@ -2,17 +2,37 @@ package uper
 
 import (
 	"fmt"
+
+	"github.com/lvdund/asn1go/internal/per"
 )
 
 var (
-	ErrCritical      error = fmt.Errorf("Critical")
-	ErrUnderflow     error = fmt.Errorf("Underflow")
-	ErrOverflow      error = fmt.Errorf("Overflow")
-	ErrTail          error = fmt.Errorf("Junk tail")
-	ErrIncomplete    error = fmt.Errorf("Data truncated")
-	ErrInextensible  error = fmt.Errorf("Field not extensible")
-	ErrFixedLength   error = fmt.Errorf("Invalid fixed length")
-	ErrConstraint    error = fmt.Errorf("Invalid constraint")
-	ErrInvalidLength error = fmt.Errorf("Invalid length")
+	ErrCritical                error = fmt.Errorf("Critical")
+	ErrUnderflow               error = fmt.Errorf("Underflow")
+	ErrOverflow                error = fmt.Errorf("Overflow")
+	ErrTail                    error = fmt.Errorf("Junk tail")
+	ErrIncomplete              error = fmt.Errorf("Data truncated")
+	ErrInextensible            error = fmt.Errorf("Field not extensible")
+	ErrFixedLength             error = fmt.Errorf("Invalid fixed length")
+	ErrConstraint              error = per.ErrConstraint
+	ErrInvalidLength           error = fmt.Errorf("Invalid length")
+	ErrUnknownValue            error = fmt.Errorf("Unknown enumeration value")
+	ErrUnknownChoice           error = fmt.Errorf("Unknown choice alternative")
+	ErrInvalidObjectIdentifier error = fmt.Errorf("Invalid object identifier")
+	ErrNonCanonical            error = per.ErrNonCanonical
 )
 
+// errStopIteration ends a SEQUENCE OF read early when its iterator is broken
+// out of.
+var errStopIteration error = fmt.Errorf("Iteration stopped")
+
+// UnknownExtensionError is returned when a decoder meets an extension addition
+// it has no definition for, typically one added by a newer release of the
+// peer. Index is the position of the addition among the extension additions.
+type UnknownExtensionError struct {
+	Index uint64
+}
+
+func (e *UnknownExtensionError) Error() string {
+	return fmt.Sprintf("Unknown extension addition %d", e.Index)
+}
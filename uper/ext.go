@@ -17,97 +17,177 @@ import "github.com/lvdund/asn1go/utils"
 //     ]]                -- No comma for last extension group
 // }
 
-func (uw *UperWriter) WriteExtBitMap(extBitmap []bool) error {
-	var err error
+// WriteExtBitMap writes the presence bitmap of the extension additions; its
+// length is a normally small length.
+func (uw *UperWriter) WriteExtBitMap(extBitmap []bool) (err error) {
 	defer func() {
-		err = utils.WrapError("writeExtBitMap", err)
+		err = utils.WrapError("WriteExtBitMap", err)
 	}()
-	
-	// Write bit indicating if > 64 bits
-	isLarge := len(extBitmap) > 64
-	if err = uw.WriteBool(isLarge); err != nil {
-		return err
+	n := uint64(len(extBitmap))
+	if n == 0 {
+		err = ErrInvalidLength
+		return
 	}
-	
-	if !isLarge {
-		// Small bitmap (<= 64 bits): write length-1 in 6 bits, then bits
-		if err = uw.writeValue(uint64(len(extBitmap)-1), 6); err != nil {
-			return err
-		}
-		// Write extension bits
-		for _, bit := range extBitmap {
-			if err = uw.WriteBool(bit); err != nil {
-				return err
-			}
+	if n <= POW_6 {
+		if err = uw.WriteBool(Zero); err != nil {
+			return
 		}
+		err = uw.writeValue(n-1, 6)
 	} else {
-		// Large bitmap (> 64 bits): use indefinite length encoding
-		// Convert bits to bytes
-		numBytes := (len(extBitmap) + 7) / 8
-		bitArray := make([]byte, numBytes)
-		for i, bit := range extBitmap {
-			if bit {
-				byteIdx := i / 8
-				bitIdx := i % 8
-				bitArray[byteIdx] |= 1 << (7 - bitIdx)
-			}
+		if err = uw.WriteBool(One); err != nil {
+			return
 		}
-		// Write using indefinite length encoding
-		if err = uw.WriteOpenType(bitArray); err != nil {
-			return err
+		err = uw.writeLength(0, n)
+	}
+	if err != nil {
+		return
+	}
+	for _, bit := range extBitmap {
+		if err = uw.WriteBool(bit); err != nil {
+			return
 		}
 	}
-	return nil
+	return
 }
 
-func (ur *UperReader) ReadExtBitMap() ([]bool, error) {
-	var err error
+// ReadExtBitMap reads the presence bitmap written by WriteExtBitMap.
+func (ur *UperReader) ReadExtBitMap() (extBitmap []bool, err error) {
 	defer func() {
-		err = utils.WrapError("readExtBitMap", err)
+		err = utils.WrapError("ReadExtBitMap", err)
 	}()
-	
-	isLarge, err := ur.ReadBool()
-	if err != nil {
-		return nil, err
+	var isLarge bool
+	if isLarge, err = ur.ReadBool(); err != nil {
+		return
 	}
-	
+	var n uint64
 	if !isLarge {
-		// Small bitmap (<= 64 bits)
-		bitmapLen, err := ur.readValue(6)
-		if err != nil {
-			return nil, err
+		if n, err = ur.readValue(6); err != nil {
+			return
+		}
+		n++
+	} else {
+		var more bool
+		if n, more, err = ur.readLength(0); err != nil {
+			return
+		}
+		if more || n == 0 {
+			err = ErrInvalidLength
+			return
 		}
-		bitmapLen++ // actual length
+	}
+	extBitmap = make([]bool, n)
+	for i := range extBitmap {
+		if extBitmap[i], err = ur.ReadBool(); err != nil {
+			return
+		}
+	}
+	return
+}
 
-		extBitmap := make([]bool, bitmapLen)
-		for i := uint64(0); i < bitmapLen; i++ {
-			bit, err := ur.ReadBool()
-			if err != nil {
-				return nil, err
+// RawExtension is an extension addition kept as its open-type octets, at
+// its position in the extension bitmap.
+type RawExtension struct {
+	Index int
+	Data  []byte
+}
+
+// RawExtensions holds the extension additions a decoder has no definition
+// for, together with the length of the bitmap they came with, so that a
+// value received from a newer peer can be re-encoded unchanged.
+type RawExtensions struct {
+	BitmapLen int
+	Additions []RawExtension
+}
+
+// IsEmpty reports whether no unknown addition was kept.
+func (raw *RawExtensions) IsEmpty() bool {
+	return raw == nil || len(raw.Additions) == 0
+}
+
+// RawOpenType is the content of an open type kept as octets, e.g. the value
+// of an unknown CHOICE alternative. Writing it with WriteOpenTypeValue
+// reproduces the original open type.
+type RawOpenType []byte
+
+func (raw RawOpenType) Encode(uw *UperWriter) error {
+	return uw.writeBytes(raw)
+}
+
+// WriteExtensionAdditions writes the bitmap and the open types of the
+// extension additions, in definition order. A nil entry is an absent
+// addition; a group is a single entry that encodes its own preamble. The
+// unknown additions in raw, if any, are written back at their positions.
+func (uw *UperWriter) WriteExtensionAdditions(additions []UperMarshaller, raw *RawExtensions) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteExtensionAdditions", err)
+	}()
+	n := len(additions)
+	unknown := make(map[int][]byte)
+	if raw != nil && raw.BitmapLen > 0 { //keep the length of the received bitmap
+		n = raw.BitmapLen
+		for _, addition := range raw.Additions {
+			unknown[addition.Index] = addition.Data
+			n = max(n, addition.Index+1)
+		}
+		for i, addition := range additions {
+			if addition != nil {
+				n = max(n, i+1)
 			}
-			extBitmap[i] = bit
 		}
-		return extBitmap, nil
-	} else {
-		// Large bitmap (> 64 bits): read using indefinite length encoding
-		byteArray, err := ur.ReadOpenType()
+	}
+	extBitmap := make([]bool, n)
+	for i := range extBitmap {
+		_, extBitmap[i] = unknown[i]
+		if i < len(additions) && additions[i] != nil {
+			extBitmap[i] = true
+		}
+	}
+	if err = uw.WriteExtBitMap(extBitmap); err != nil {
+		return
+	}
+	for i, present := range extBitmap {
+		if !present {
+			continue
+		}
+		if i < len(additions) && additions[i] != nil {
+			err = uw.WriteOpenTypeValue(additions[i])
+		} else {
+			err = uw.WriteOpenType(unknown[i])
+		}
 		if err != nil {
-			return nil, err
-		}
-		
-		// Convert bytes back to bits
-		extBitmap := make([]bool, len(byteArray)*8)
-		for i := 0; i < len(byteArray); i++ {
-			for j := 0; j < 8; j++ {
-				bitIdx := i*8 + j
-				if bitIdx < len(extBitmap) {
-					extBitmap[bitIdx] = (byteArray[i] & (1 << (7 - j))) != 0
-				}
+			return
+		}
+	}
+	return
+}
+
+// ReadExtensionAdditions reads the extension additions written by
+// WriteExtensionAdditions. Each present addition is decoded into the entry at
+// its position; additions beyond the known ones, or with a nil entry, are
+// returned in raw. The returned bitmap tells which additions were present.
+func (ur *UperReader) ReadExtensionAdditions(additions []UperUnmarshaller) (extBitmap []bool, raw RawExtensions, err error) {
+	defer func() {
+		err = utils.WrapError("ReadExtensionAdditions", err)
+	}()
+	if extBitmap, err = ur.ReadExtBitMap(); err != nil {
+		return
+	}
+	raw.BitmapLen = len(extBitmap)
+	for i, present := range extBitmap {
+		if !present {
+			continue
+		}
+		if i < len(additions) && additions[i] != nil {
+			err = ur.ReadOpenTypeValue(additions[i])
+		} else {
+			var data []byte
+			if data, err = ur.ReadOpenType(); err == nil {
+				raw.Additions = append(raw.Additions, RawExtension{Index: i, Data: data})
 			}
 		}
-		// Trim to actual length (may need to read length from encoding)
-		// Actually, we need to know the exact number of bits...
-		// This is more complex - the indefinite length encoding includes the length
-		return extBitmap, nil
+		if err != nil {
+			return
+		}
 	}
-}
\ No newline at end of file
+	return
+}
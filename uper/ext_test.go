@@ -0,0 +1,54 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// bitmaps with the first and the last addition present
+func TestUperWriter_WriteExtBitMap(t *testing.T) {
+	tests := []struct {
+		n        int
+		expected string
+	}{
+		{1, "01"},
+		{64, "7f0000000000000002"},
+		{65, "a0c00000000000000040"},
+		{100, "b240000000000000000000000008"},
+		{200, "c0644000000000000000000000000000000000000000000000000080"},
+	}
+	for _, tt := range tests {
+		bitmap := make([]bool, tt.n)
+		bitmap[0], bitmap[tt.n-1] = true, true
+
+		var buf bytes.Buffer
+		uw := NewWriter(&buf)
+		if err := uw.WriteExtBitMap(bitmap); err != nil {
+			t.Fatalf("WriteExtBitMap(%d) error = %v", tt.n, err)
+		}
+		if err := uw.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if got := hex.EncodeToString(buf.Bytes()); got != tt.expected {
+			t.Errorf("WriteExtBitMap(%d) = %s, want %s", tt.n, got, tt.expected)
+		}
+
+		got, err := NewReader(bytes.NewReader(buf.Bytes())).ReadExtBitMap()
+		if err != nil {
+			t.Fatalf("ReadExtBitMap(%d) error = %v", tt.n, err)
+		}
+		if len(got) != tt.n {
+			t.Fatalf("ReadExtBitMap() returned %d bits, want %d", len(got), tt.n)
+		}
+		for i := range got {
+			if got[i] != bitmap[i] {
+				t.Errorf("ReadExtBitMap(%d) bit %d = %v", tt.n, i, got[i])
+			}
+		}
+	}
+
+	if err := NewWriter(new(bytes.Buffer)).WriteExtBitMap(nil); err == nil {
+		t.Error("expected an error for an empty bitmap")
+	}
+}
@@ -0,0 +1,295 @@
+package uper
+
+import (
+	"github.com/lvdund/asn1go/utils"
+)
+
+// Alternatives of the identification CHOICE of EMBEDDED PDV and CHARACTER
+// STRING (X.680 clauses 36.5 and 44.5), as CHOICE indexes.
+const (
+	IdentificationSyntaxes uint64 = iota + 1
+	IdentificationSyntax
+	IdentificationPresentationContextID
+	IdentificationContextNegotiation
+	IdentificationTransferSyntax
+	IdentificationFixed
+)
+
+// Identification tells how the abstract and transfer syntaxes of an embedded
+// value are identified. The fields used depend on Choice:
+//
+//	syntaxes                 Abstract, Transfer
+//	syntax                   Abstract
+//	presentation-context-id  PresentationContextID
+//	context-negotiation      PresentationContextID, Transfer
+//	transfer-syntax          Transfer
+//	fixed                    none
+type Identification struct {
+	Choice                uint64
+	Abstract              ObjectIdentifier
+	Transfer              ObjectIdentifier
+	PresentationContextID int64
+}
+
+func (id *Identification) Encode(uw *UperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("Identification", err)
+	}()
+	if err = uw.WriteChoice(id.Choice, IdentificationFixed-1, false); err != nil {
+		return
+	}
+	switch id.Choice {
+	case IdentificationSyntaxes:
+		if err = uw.WriteObjectIdentifier(id.Abstract); err != nil {
+			return
+		}
+		err = uw.WriteObjectIdentifier(id.Transfer)
+	case IdentificationSyntax:
+		err = uw.WriteObjectIdentifier(id.Abstract)
+	case IdentificationPresentationContextID:
+		err = uw.WriteInteger(id.PresentationContextID, nil, false)
+	case IdentificationContextNegotiation:
+		if err = uw.WriteInteger(id.PresentationContextID, nil, false); err != nil {
+			return
+		}
+		err = uw.WriteObjectIdentifier(id.Transfer)
+	case IdentificationTransferSyntax:
+		err = uw.WriteObjectIdentifier(id.Transfer)
+	}
+	return
+}
+
+func (id *Identification) Decode(ur *UperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("Identification", err)
+	}()
+	*id = Identification{}
+	if id.Choice, err = ur.ReadChoice(IdentificationFixed-1, false); err != nil {
+		return
+	}
+	switch id.Choice {
+	case IdentificationSyntaxes:
+		if id.Abstract, err = ur.ReadObjectIdentifier(); err != nil {
+			return
+		}
+		id.Transfer, err = ur.ReadObjectIdentifier()
+	case IdentificationSyntax:
+		id.Abstract, err = ur.ReadObjectIdentifier()
+	case IdentificationPresentationContextID:
+		id.PresentationContextID, err = ur.ReadInteger(nil, false)
+	case IdentificationContextNegotiation:
+		if id.PresentationContextID, err = ur.ReadInteger(nil, false); err != nil {
+			return
+		}
+		id.Transfer, err = ur.ReadObjectIdentifier()
+	case IdentificationTransferSyntax:
+		id.Transfer, err = ur.ReadObjectIdentifier()
+	case IdentificationFixed:
+	default:
+		err = ErrUnknownChoice
+	}
+	return
+}
+
+// Alternatives of the encoding CHOICE of EXTERNAL, as CHOICE indexes.
+const (
+	ExternalSingleASN1Type uint64 = iota + 1
+	ExternalOctetAligned
+	ExternalArbitrary
+)
+
+// External is an EXTERNAL value, encoded as the SEQUENCE of X.691 clause 29:
+//
+//	SEQUENCE {
+//		direct-reference      OBJECT IDENTIFIER OPTIONAL,
+//		indirect-reference    INTEGER OPTIONAL,
+//		data-value-descriptor ObjectDescriptor OPTIONAL,
+//		encoding CHOICE {
+//			single-ASN1-type [0] ABSTRACT-SYNTAX.&Type,
+//			octet-aligned    [1] IMPLICIT OCTET STRING,
+//			arbitrary        [2] IMPLICIT BIT STRING } }
+//
+// The syntax identification is a direct reference, the presentation context
+// one an indirect reference and context negotiation both. DataValue holds the
+// open type octets of a single ASN.1 type, the octets of an octet-aligned
+// value or the NumBits bits of an arbitrary one; see DecodeValue.
+type External struct {
+	DirectReference     ObjectIdentifier //nil if absent
+	IndirectReference   *int64
+	DataValueDescriptor *string
+	Encoding            uint64
+	DataValue           []byte
+	NumBits             uint64 //arbitrary only; 0 means all the bits of DataValue
+}
+
+func (x *External) Encode(uw *UperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("External", err)
+	}()
+	for _, present := range []bool{x.DirectReference != nil, x.IndirectReference != nil, x.DataValueDescriptor != nil} {
+		if err = uw.WriteBool(present); err != nil {
+			return
+		}
+	}
+	if x.DirectReference != nil {
+		if err = uw.WriteObjectIdentifier(x.DirectReference); err != nil {
+			return
+		}
+	}
+	if x.IndirectReference != nil {
+		if err = uw.WriteInteger(*x.IndirectReference, nil, false); err != nil {
+			return
+		}
+	}
+	if x.DataValueDescriptor != nil { //an ObjectDescriptor is encoded like an OCTET STRING
+		if err = uw.WriteOctetString([]byte(*x.DataValueDescriptor), nil, false); err != nil {
+			return
+		}
+	}
+	if err = uw.WriteChoice(x.Encoding, ExternalArbitrary-1, false); err != nil {
+		return
+	}
+	switch x.Encoding {
+	case ExternalSingleASN1Type:
+		err = uw.WriteOpenType(x.DataValue)
+	case ExternalOctetAligned:
+		err = uw.WriteOctetString(x.DataValue, nil, false)
+	case ExternalArbitrary:
+		nbits := x.NumBits
+		if nbits == 0 {
+			nbits = uint64(8 * len(x.DataValue))
+		}
+		err = uw.WriteBitString(x.DataValue, uint(nbits), nil, false)
+	}
+	return
+}
+
+func (x *External) Decode(ur *UperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("External", err)
+	}()
+	*x = External{}
+	var present [3]bool
+	for i := range present {
+		if present[i], err = ur.ReadBool(); err != nil {
+			return
+		}
+	}
+	if present[0] {
+		if x.DirectReference, err = ur.ReadObjectIdentifier(); err != nil {
+			return
+		}
+	}
+	if present[1] {
+		var v int64
+		if v, err = ur.ReadInteger(nil, false); err != nil {
+			return
+		}
+		x.IndirectReference = &v
+	}
+	if present[2] {
+		var content []byte
+		if content, err = ur.ReadOctetString(nil, false); err != nil {
+			return
+		}
+		descriptor := string(content)
+		x.DataValueDescriptor = &descriptor
+	}
+	if x.Encoding, err = ur.ReadChoice(ExternalArbitrary-1, false); err != nil {
+		return
+	}
+	switch x.Encoding {
+	case ExternalSingleASN1Type:
+		x.DataValue, err = ur.ReadOpenType()
+	case ExternalOctetAligned:
+		x.DataValue, err = ur.ReadOctetString(nil, false)
+	case ExternalArbitrary:
+		var nbits uint
+		x.DataValue, nbits, err = ur.ReadBitString(nil, false)
+		x.NumBits = uint64(nbits)
+	default:
+		err = ErrUnknownChoice
+	}
+	return
+}
+
+// DecodeValue decodes the data value into v with the unaligned variant. A
+// value in another encoding can be decoded from DataValue directly.
+func (x *External) DecodeValue(v UperUnmarshaller) error {
+	if x.Encoding == ExternalArbitrary && x.NumBits > 0 {
+		return v.Decode(NewReaderBits(x.DataValue, uint(x.NumBits)))
+	}
+	return Unmarshal(x.DataValue, v)
+}
+
+// EmbeddedPDV is an EMBEDDED PDV value, encoded as the SEQUENCE of X.680
+// clause 36.5 without its data-value-descriptor, which is always absent:
+//
+//	SEQUENCE {
+//		identification Identification,
+//		data-value     OCTET STRING }
+type EmbeddedPDV struct {
+	Identification Identification
+	DataValue      []byte
+}
+
+func (p *EmbeddedPDV) Encode(uw *UperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("EmbeddedPDV", err)
+	}()
+	if err = p.Identification.Encode(uw); err != nil {
+		return
+	}
+	err = uw.WriteOctetString(p.DataValue, nil, false)
+	return
+}
+
+func (p *EmbeddedPDV) Decode(ur *UperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("EmbeddedPDV", err)
+	}()
+	if err = p.Identification.Decode(ur); err != nil {
+		return
+	}
+	p.DataValue, err = ur.ReadOctetString(nil, false)
+	return
+}
+
+// DecodeValue decodes the data value into v with the unaligned variant. A
+// value in another transfer syntax can be decoded from DataValue directly.
+func (p *EmbeddedPDV) DecodeValue(v UperUnmarshaller) error {
+	return Unmarshal(p.DataValue, v)
+}
+
+// CharacterString is an unrestricted CHARACTER STRING value, encoded as the
+// SEQUENCE of X.680 clause 44.5 without its data-value-descriptor:
+//
+//	SEQUENCE {
+//		identification Identification,
+//		string-value   OCTET STRING }
+type CharacterString struct {
+	Identification Identification
+	StringValue    []byte
+}
+
+func (s *CharacterString) Encode(uw *UperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("CharacterString", err)
+	}()
+	if err = s.Identification.Encode(uw); err != nil {
+		return
+	}
+	err = uw.WriteOctetString(s.StringValue, nil, false)
+	return
+}
+
+func (s *CharacterString) Decode(ur *UperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("CharacterString", err)
+	}()
+	if err = s.Identification.Decode(ur); err != nil {
+		return
+	}
+	s.StringValue, err = ur.ReadOctetString(nil, false)
+	return
+}
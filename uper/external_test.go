@@ -0,0 +1,95 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// basic encoding rules, {joint-iso-itu-t asn1(1) basic-encoding(1)}
+var berSyntax = ObjectIdentifier{2, 1, 1}
+
+func TestExternal(t *testing.T) {
+	three, descriptor := int64(3), "pair"
+	pair, err := Marshal(&testPair{Flag: true, Value: 5})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		value    External
+		expected string
+	}{
+		{"syntax, octet-aligned", External{DirectReference: berSyntax, Encoding: ExternalOctetAligned, DataValue: []byte{1, 2}}, "804a2028100810"},
+		{"context negotiation, single type", External{DirectReference: berSyntax, IndirectReference: &three, Encoding: ExternalSingleASN1Type, DataValue: pair}, ""},
+		{"descriptor, arbitrary", External{IndirectReference: &three, DataValueDescriptor: &descriptor, Encoding: ExternalArbitrary, DataValue: []byte{0xa0}, NumBits: 3}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeTest(t, tt.value.Encode)
+			if tt.expected != "" && hex.EncodeToString(got) != tt.expected {
+				t.Errorf("Encode() = %x, want %s", got, tt.expected)
+			}
+			var x External
+			if err := x.Decode(NewReader(bytes.NewReader(got))); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(x, tt.value) {
+				t.Errorf("Decode() = %+v, want %+v", x, tt.value)
+			}
+		})
+	}
+
+	x := External{DirectReference: berSyntax, Encoding: ExternalSingleASN1Type, DataValue: pair}
+	var v testPair
+	if err := x.DecodeValue(&v); err != nil {
+		t.Fatalf("DecodeValue() error = %v", err)
+	}
+	if v != (testPair{Flag: true, Value: 5}) {
+		t.Errorf("DecodeValue() = %+v", v)
+	}
+}
+
+func TestEmbeddedPDV(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    EmbeddedPDV
+		expected string
+	}{
+		{"presentation-context-id", EmbeddedPDV{Identification{Choice: IdentificationPresentationContextID, PresentationContextID: 3}, []byte{0xab}}, "4020603560"},
+		{"syntaxes", EmbeddedPDV{Identification{Choice: IdentificationSyntaxes, Abstract: ObjectIdentifier{1, 0, 8571}, Transfer: berSyntax}, []byte{1}}, ""},
+		{"context-negotiation", EmbeddedPDV{Identification{Choice: IdentificationContextNegotiation, PresentationContextID: 1, Transfer: berSyntax}, []byte{1}}, ""},
+		{"transfer-syntax", EmbeddedPDV{Identification{Choice: IdentificationTransferSyntax, Transfer: berSyntax}, []byte{1}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeTest(t, tt.value.Encode)
+			if tt.expected != "" && hex.EncodeToString(got) != tt.expected {
+				t.Errorf("Encode() = %x, want %s", got, tt.expected)
+			}
+			var p EmbeddedPDV
+			if err := p.Decode(NewReader(bytes.NewReader(got))); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(p, tt.value) {
+				t.Errorf("Decode() = %+v, want %+v", p, tt.value)
+			}
+		})
+	}
+}
+
+func TestCharacterString(t *testing.T) {
+	s := CharacterString{Identification{Choice: IdentificationFixed}, []byte("hi")}
+	got := encodeTest(t, s.Encode)
+	if want := "a04d0d20"; hex.EncodeToString(got) != want {
+		t.Errorf("Encode() = %x, want %s", got, want)
+	}
+	var decoded CharacterString
+	if err := decoded.Decode(NewReader(bytes.NewReader(got))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, s) {
+		t.Errorf("Decode() = %+v, want %+v", decoded, s)
+	}
+}
@@ -0,0 +1,100 @@
+package uper
+
+// Bounds supplies a PER-visible constraint as a type, so that the constraint
+// of a field is declared once and used by both Encode and Decode:
+//
+//	type portRange struct{}
+//
+//	func (portRange) Constraint() *PerConstraint { return Bounded(0, 65535).Extend() }
+//
+//	type Port = ConstrainedInt[portRange] // INTEGER (0..65535, ...)
+//
+// Any constraint built with Bounded, AtLeast, AtMost or Unbounded will do,
+// extension additions included. For the sized types the constraint is on the
+// number of octets or bits.
+type Bounds interface {
+	Constraint() *PerConstraint
+}
+
+// constraintOf returns the constraint supplied by the bounds type B.
+func constraintOf[B Bounds]() *PerConstraint {
+	var b B
+	return b.Constraint()
+}
+
+// validateSize checks that n satisfies the SIZE constraint pc.
+func validateSize(n uint64, pc *PerConstraint) error {
+	lb, ub, hasUb, err := pc.SizeBounds()
+	if err != nil {
+		return err
+	}
+	if (n >= lb && (!hasUb || n <= ub)) || pc.IsExtensible() {
+		return nil
+	}
+	if lb == ub {
+		return ErrFixedLength
+	}
+	return ErrInextensible
+}
+
+// ConstrainedInt is an INTEGER constrained by B.
+type ConstrainedInt[B Bounds] int64
+
+func (v *ConstrainedInt[B]) Validate() error {
+	pc := constraintOf[B]()
+	if err := pc.Validate(); err != nil {
+		return err
+	}
+	if !pc.InRoot(int64(*v)) && !pc.InExtension(int64(*v)) {
+		return ErrInextensible
+	}
+	return nil
+}
+
+func (v *ConstrainedInt[B]) Encode(uw *UperWriter) error {
+	return uw.WriteIntegerWith(int64(*v), constraintOf[B]())
+}
+
+func (v *ConstrainedInt[B]) Decode(ur *UperReader) error {
+	value, err := ur.ReadIntegerWith(constraintOf[B]())
+	if err != nil {
+		return err
+	}
+	*v = ConstrainedInt[B](value)
+	return nil
+}
+
+// SizedOctets is an OCTET STRING whose size is constrained by B.
+type SizedOctets[B Bounds] []byte
+
+func (v *SizedOctets[B]) Validate() error {
+	return validateSize(uint64(len(*v)), constraintOf[B]())
+}
+
+func (v *SizedOctets[B]) Encode(uw *UperWriter) error {
+	return uw.WriteOctetStringWith(*v, constraintOf[B]())
+}
+
+func (v *SizedOctets[B]) Decode(ur *UperReader) error {
+	value, err := ur.ReadOctetStringWith(constraintOf[B]())
+	if err != nil {
+		return err
+	}
+	*v = value
+	return nil
+}
+
+// SizedBits is a BIT STRING whose size is constrained by B.
+type SizedBits[B Bounds] BitString
+
+func (v *SizedBits[B]) Validate() error {
+	return validateSize(v.NumBits, constraintOf[B]())
+}
+
+func (v *SizedBits[B]) Encode(uw *UperWriter) error {
+	return (*BitString)(v).Encode(uw, constraintOf[B]())
+}
+
+func (v *SizedBits[B]) Decode(ur *UperReader) error {
+	return (*BitString)(v).Decode(ur, constraintOf[B]())
+}
@@ -0,0 +1,161 @@
+package uper
+
+import "github.com/lvdund/asn1go/utils"
+
+// WriteIntegerList encodes a SEQUENCE OF INTEGER: size constrains the number
+// of elements and elem every element.
+func (uw *UperWriter) WriteIntegerList(vals []int64, size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) error {
+	return uw.WriteIntegerListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// WriteIntegerListWith encodes a SEQUENCE OF INTEGER with PER-visible size and
+// element constraints.
+func (uw *UperWriter) WriteIntegerListWith(vals []int64, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteIntegerList", err)
+	}()
+	err = uw.writeSequenceOf(len(vals), size, func(i int) error { return uw.WriteIntegerWith(vals[i], elem) })
+	return
+}
+
+// ReadIntegerList decodes a SEQUENCE OF INTEGER written by WriteIntegerList
+// with the same constraints.
+func (ur *UperReader) ReadIntegerList(size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) ([]int64, error) {
+	return ur.ReadIntegerListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// ReadIntegerListWith decodes a SEQUENCE OF INTEGER with PER-visible size and
+// element constraints.
+func (ur *UperReader) ReadIntegerListWith(size, elem *PerConstraint) (vals []int64, err error) {
+	defer func() {
+		err = utils.WrapError("ReadIntegerList", err)
+	}()
+	err = ur.readSequenceOf(size, func() error {
+		v, err := ur.ReadIntegerWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, v)
+		return nil
+	})
+	return
+}
+
+// WriteOctetStringList encodes a SEQUENCE OF OCTET STRING: size constrains the
+// number of elements and elem the size of every element.
+func (uw *UperWriter) WriteOctetStringList(vals [][]byte, size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) error {
+	return uw.WriteOctetStringListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// WriteOctetStringListWith encodes a SEQUENCE OF OCTET STRING with PER-visible
+// size constraints on the list and on its elements.
+func (uw *UperWriter) WriteOctetStringListWith(vals [][]byte, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteOctetStringList", err)
+	}()
+	err = uw.writeSequenceOf(len(vals), size, func(i int) error { return uw.WriteOctetStringWith(vals[i], elem) })
+	return
+}
+
+// ReadOctetStringList decodes a SEQUENCE OF OCTET STRING written by
+// WriteOctetStringList with the same constraints.
+func (ur *UperReader) ReadOctetStringList(size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) ([][]byte, error) {
+	return ur.ReadOctetStringListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// ReadOctetStringListWith decodes a SEQUENCE OF OCTET STRING with PER-visible
+// size constraints on the list and on its elements.
+func (ur *UperReader) ReadOctetStringListWith(size, elem *PerConstraint) (vals [][]byte, err error) {
+	defer func() {
+		err = utils.WrapError("ReadOctetStringList", err)
+	}()
+	err = ur.readSequenceOf(size, func() error {
+		v, err := ur.ReadOctetStringWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, v)
+		return nil
+	})
+	return
+}
+
+// WriteBitStringList encodes a SEQUENCE OF BIT STRING: size constrains the
+// number of elements and elem the size of every element.
+func (uw *UperWriter) WriteBitStringList(vals []BitString, size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) error {
+	return uw.WriteBitStringListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// WriteBitStringListWith encodes a SEQUENCE OF BIT STRING with PER-visible
+// size constraints on the list and on its elements.
+func (uw *UperWriter) WriteBitStringListWith(vals []BitString, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteBitStringList", err)
+	}()
+	err = uw.writeSequenceOf(len(vals), size, func(i int) error {
+		return uw.WriteBitStringWith(vals[i].Bytes, uint(vals[i].NumBits), elem)
+	})
+	return
+}
+
+// ReadBitStringList decodes a SEQUENCE OF BIT STRING written by
+// WriteBitStringList with the same constraints.
+func (ur *UperReader) ReadBitStringList(size *Constraint, sizeExt bool, elem *Constraint, elemExt bool) ([]BitString, error) {
+	return ur.ReadBitStringListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(elem, elemExt))
+}
+
+// ReadBitStringListWith decodes a SEQUENCE OF BIT STRING with PER-visible size
+// constraints on the list and on its elements.
+func (ur *UperReader) ReadBitStringListWith(size, elem *PerConstraint) (vals []BitString, err error) {
+	defer func() {
+		err = utils.WrapError("ReadBitStringList", err)
+	}()
+	err = ur.readSequenceOf(size, func() error {
+		content, nbits, err := ur.ReadBitStringWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, BitString{Bytes: content, NumBits: uint64(nbits)})
+		return nil
+	})
+	return
+}
+
+// WriteEnumList encodes a SEQUENCE OF ENUMERATED: size constrains the number
+// of elements and elem the index of every element.
+func (uw *UperWriter) WriteEnumList(vals []uint64, size *Constraint, sizeExt bool, elem Constraint, elemExt bool) error {
+	return uw.WriteEnumListWith(vals, NewPerConstraint(size, sizeExt), NewPerConstraint(&elem, elemExt))
+}
+
+// WriteEnumListWith encodes a SEQUENCE OF ENUMERATED with PER-visible size and
+// element constraints; elem must have both bounds.
+func (uw *UperWriter) WriteEnumListWith(vals []uint64, size, elem *PerConstraint) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteEnumList", err)
+	}()
+	err = uw.writeSequenceOf(len(vals), size, func(i int) error { return uw.WriteEnumerateWith(vals[i], elem) })
+	return
+}
+
+// ReadEnumList decodes a SEQUENCE OF ENUMERATED written by WriteEnumList with
+// the same constraints.
+func (ur *UperReader) ReadEnumList(size *Constraint, sizeExt bool, elem Constraint, elemExt bool) ([]uint64, error) {
+	return ur.ReadEnumListWith(NewPerConstraint(size, sizeExt), NewPerConstraint(&elem, elemExt))
+}
+
+// ReadEnumListWith decodes a SEQUENCE OF ENUMERATED with PER-visible size and
+// element constraints.
+func (ur *UperReader) ReadEnumListWith(size, elem *PerConstraint) (vals []uint64, err error) {
+	defer func() {
+		err = utils.WrapError("ReadEnumList", err)
+	}()
+	err = ur.readSequenceOf(size, func() error {
+		v, err := ur.ReadEnumerateWith(elem)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, v)
+		return nil
+	})
+	return
+}
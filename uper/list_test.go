@@ -0,0 +1,88 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestIntegerList(t *testing.T) {
+	size, elem := &Constraint{Lb: 1, Ub: 8}, &Constraint{Lb: 0, Ub: 255}
+	vals := []int64{1, 2, 3}
+	got := encodeTest(t, func(uw *UperWriter) error { return uw.WriteIntegerList(vals, size, false, elem, false) })
+	if want := "40204060"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteIntegerList() = %x, want %s", got, want)
+	}
+	decoded, err := NewReader(bytes.NewReader(got)).ReadIntegerList(size, false, elem, false)
+	if err != nil {
+		t.Fatalf("ReadIntegerList() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, vals) {
+		t.Errorf("ReadIntegerList() = %v, want %v", decoded, vals)
+	}
+
+	// the element constraint is extensible, the size is not
+	got = encodeTest(t, func(uw *UperWriter) error { return uw.WriteIntegerList([]int64{300}, size, false, elem, true) })
+	if want := "102012c0"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteIntegerList(extended element) = %x, want %s", got, want)
+	}
+	if err := NewWriter(new(bytes.Buffer)).WriteIntegerList(make([]int64, 9), size, false, elem, false); err == nil {
+		t.Error("expected an error for a list above the SIZE constraint")
+	}
+
+	// a list cut short in its third element keeps only the elements read whole
+	truncated, _ := hex.DecodeString("402040")
+	decoded, err = NewReader(bytes.NewReader(truncated)).ReadIntegerList(size, false, elem, false)
+	if err == nil || !reflect.DeepEqual(decoded, vals[:2]) {
+		t.Errorf("ReadIntegerList(truncated) = %v, %v, want %v and an error", decoded, err, vals[:2])
+	}
+}
+
+func TestOctetStringList(t *testing.T) {
+	size, elem := &Constraint{Lb: 0, Ub: 2}, &Constraint{Lb: 2, Ub: 2}
+	vals := [][]byte{{0xab, 0xcd}, {0x01, 0x02}}
+	got := encodeTest(t, func(uw *UperWriter) error { return uw.WriteOctetStringList(vals, size, false, elem, false) })
+	if want := "aaf3404080"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteOctetStringList() = %x, want %s", got, want)
+	}
+	decoded, err := NewReader(bytes.NewReader(got)).ReadOctetStringList(size, false, elem, false)
+	if err != nil {
+		t.Fatalf("ReadOctetStringList() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, vals) {
+		t.Errorf("ReadOctetStringList() = %x, want %x", decoded, vals)
+	}
+}
+
+func TestBitStringList(t *testing.T) {
+	size, elem := &Constraint{Lb: 1, Ub: 4}, &Constraint{Lb: 0, Ub: 7}
+	vals := []BitString{{Bytes: []byte{0xa0}, NumBits: 3}, {Bytes: []byte{0xfe}, NumBits: 7}}
+	got := encodeTest(t, func(uw *UperWriter) error { return uw.WriteBitStringList(vals, size, false, elem, false) })
+	if want := "5dffc0"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteBitStringList() = %x, want %s", got, want)
+	}
+	decoded, err := NewReader(bytes.NewReader(got)).ReadBitStringList(size, false, elem, false)
+	if err != nil {
+		t.Fatalf("ReadBitStringList() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, vals) {
+		t.Errorf("ReadBitStringList() = %v, want %v", decoded, vals)
+	}
+}
+
+func TestEnumList(t *testing.T) {
+	size, elem := &Constraint{Lb: 0, Ub: 3}, Constraint{Lb: 0, Ub: 2}
+	vals := []uint64{2, 0, 4}
+	got := encodeTest(t, func(uw *UperWriter) error { return uw.WriteEnumList(vals, size, false, elem, true) })
+	if want := "d081"; hex.EncodeToString(got) != want {
+		t.Errorf("WriteEnumList() = %x, want %s", got, want)
+	}
+	decoded, err := NewReader(bytes.NewReader(got)).ReadEnumList(size, false, elem, true)
+	if err != nil {
+		t.Fatalf("ReadEnumList() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, vals) {
+		t.Errorf("ReadEnumList() = %v, want %v", decoded, vals)
+	}
+}
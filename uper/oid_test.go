@@ -0,0 +1,57 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestObjectIdentifier(t *testing.T) {
+	tests := []struct {
+		oid      ObjectIdentifier
+		expected string
+	}{
+		{ObjectIdentifier{1, 2, 840, 113549}, "062a864886f70d"},
+		{ObjectIdentifier{2, 999, 3}, "03883703"},
+		{ObjectIdentifier{0, 0}, "0100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.oid.String(), func(t *testing.T) {
+			got := encodeTest(t, func(uw *UperWriter) error { return uw.WriteObjectIdentifier(tt.oid) })
+			if hex.EncodeToString(got) != tt.expected {
+				t.Errorf("WriteObjectIdentifier() = %x, want %s", got, tt.expected)
+			}
+			oid, err := NewReader(bytes.NewReader(got)).ReadObjectIdentifier()
+			if err != nil {
+				t.Fatalf("ReadObjectIdentifier() error = %v", err)
+			}
+			if !oid.Equal(tt.oid) {
+				t.Errorf("ReadObjectIdentifier() = %v, want %v", oid, tt.oid)
+			}
+		})
+	}
+
+	// the length and the contents follow a leading bit without padding
+	got := encodeTest(t, func(uw *UperWriter) error {
+		if err := uw.WriteBool(true); err != nil {
+			return err
+		}
+		return uw.WriteObjectIdentifier(ObjectIdentifier{0, 0})
+	})
+	if hex.EncodeToString(got) != "808000" {
+		t.Errorf("WriteObjectIdentifier() after a bit = %x, want 808000", got)
+	}
+
+	for _, oid := range []ObjectIdentifier{{1}, {3, 1}, {1, 40}} {
+		if err := NewWriter(new(bytes.Buffer)).WriteObjectIdentifier(oid); !errors.Is(err, ErrInvalidObjectIdentifier) {
+			t.Errorf("WriteObjectIdentifier(%v) error = %v, want ErrInvalidObjectIdentifier", oid, err)
+		}
+	}
+	for _, encoded := range []string{"00", "028001", "0186"} {
+		b, _ := hex.DecodeString(encoded)
+		if _, err := NewReader(bytes.NewReader(b)).ReadObjectIdentifier(); !errors.Is(err, ErrInvalidObjectIdentifier) {
+			t.Errorf("ReadObjectIdentifier(%s) error = %v, want ErrInvalidObjectIdentifier", encoded, err)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 type UperReader struct {
 	*bitstreamReader
+	canonical bool
 }
 
 func NewReader(r io.Reader) *UperReader {
@@ -19,6 +20,20 @@ func NewReader(r io.Reader) *UperReader {
 	}
 }
 
+// SetCanonical makes the reader accept CANONICAL-PER encodings only: a whole
+// number with more octets than it needs, a component encoded with its
+// DEFAULT value, an unsorted SET OF or a named bit string with trailing 0
+// bits fail with ErrNonCanonical, in the values read as open types or
+// contained encodings too.
+func (ur *UperReader) SetCanonical(canonical bool) {
+	ur.canonical = canonical
+}
+
+// Canonical reports whether the reader only accepts CANONICAL-PER.
+func (ur *UperReader) Canonical() bool {
+	return ur.canonical
+}
+
 func (ur *UperReader) readBytes(nbytes uint) (output []byte, err error) {
 	return ur.ReadBits(nbytes * 8)
 }
@@ -45,28 +60,65 @@ func (ur *UperReader) readValue(nbits uint) (v uint64, err error) {
 	return
 }
 
-// readConstraintValue for UPER (no alignment)
+// readConstraintValue reads one of a range of r values written by
+// writeConstraintValue.
 func (ur *UperReader) readConstraintValue(r uint64) (v uint64, err error) {
 	defer func() {
 		err = utils.WrapError("readConstraintValue", err)
 	}()
 
-	if r < POW_8 {
-		// UPER: read value bits directly
-		v, err = ur.readValue(uint(bits.Len64(r - 1)))
+	if r == 0 {
+		err = ErrOverflow
 		return
-	} else if r == POW_8 {
-		// UPER: no alignment, read 8 bits
-		v, err = ur.readValue(8)
+	}
+	v, err = ur.readValue(uint(bits.Len64(r - 1)))
+	return
+}
+
+// readConstrainedWholeNumber decodes the offset from the lower bound of a
+// value whose range is span+1 (see writeConstrainedWholeNumber)
+func (ur *UperReader) readConstrainedWholeNumber(span uint64) (v uint64, err error) {
+	defer func() {
+		err = utils.WrapError("readConstrainedWholeNumber", err)
+	}()
+
+	if span == 0 {
 		return
-	} else if r <= POW_16 {
-		// UPER: no alignment, read 16 bits
-		v, err = ur.readValue(16)
+	}
+	if v, err = ur.readValue(uint(bits.Len64(span))); err != nil {
 		return
-	} else {
+	}
+	if v > span {
 		err = ErrOverflow
+	}
+	return
+}
+
+// readUnconstrainedWholeNumber decodes a length determinant followed by a two's
+// complement value
+func (ur *UperReader) readUnconstrainedWholeNumber() (v int64, err error) {
+	defer func() {
+		err = utils.WrapError("readUnconstrainedWholeNumber", err)
+	}()
+
+	var length uint64
+	if length, _, err = ur.readLength(0); err != nil {
+		return
+	}
+	if length == 0 || length > 8 {
+		err = ErrInvalidLength
+		return
+	}
+	var raw uint64
+	if raw, err = ur.readValue(uint(length) * 8); err != nil {
 		return
 	}
+	shift := 64 - uint(length)*8
+	v = int64(raw<<shift) >> shift
+	if ur.canonical && signedOctetsOf(v) != uint(length) {
+		err = ErrNonCanonical
+	}
+	return
 }
 
 // readSemiConstraintWholeNumber for UPER (no alignment)
@@ -77,12 +129,20 @@ func (ur *UperReader) readSemiConstraintWholeNumber(lb uint64) (v uint64, err er
 
 	// UPER: no alignment
 	var length uint64
-	if length, err = ur.readValue(8); err != nil {
+	if length, _, err = ur.readLength(0); err != nil {
+		return
+	}
+	if length == 0 || length > 8 {
+		err = ErrInvalidLength
 		return
 	}
 	if v, err = ur.readValue(uint(length) * 8); err != nil {
 		return
 	}
+	if ur.canonical && octetsOf(v) != uint(length) {
+		err = ErrNonCanonical
+		return
+	}
 	v += lb
 	return
 }
@@ -148,7 +208,14 @@ func (ur *UperReader) readLength(lRange uint64) (value uint64, more bool, err er
 	return
 }
 
+// ReadString is the legacy form of ReadStringWith.
 func (ur *UperReader) ReadString(c *Constraint, e bool, isBitstring bool) (content []byte, nbits uint, err error) {
+	return ur.ReadStringWith(NewPerConstraint(c, e), isBitstring)
+}
+
+// ReadStringWith decodes a BIT STRING (isBitstring) or an OCTET STRING whose
+// size is constrained by pc. nbits is the length of content in bits.
+func (ur *UperReader) ReadStringWith(pc *PerConstraint, isBitstring bool) (content []byte, nbits uint, err error) {
 	defer func() {
 		if isBitstring {
 			err = utils.WrapError("ReadString BitString", err)
@@ -157,19 +224,36 @@ func (ur *UperReader) ReadString(c *Constraint, e bool, isBitstring bool) (conte
 		}
 	}()
 
-	lRange, lowerBound, err := ur.readExBit(c, e)
+	lb, ub, hasUb, err := pc.SizeBounds()
 	if err != nil {
-		return nil, 0, err
+		return
+	}
+	if pc.IsExtensible() {
+		var exBit bool
+		if exBit, err = ur.ReadBool(); err != nil {
+			return
+		}
+		if exBit { // Outside the extension root the size is semi-constrained
+			lb, hasUb = 0, false
+		}
 	}
 
-	if lRange == 1 {
-		var numBytes uint
+	if hasUb && ub < POW_16 {
+		length := lb
+		if lb != ub {
+			if length, err = ur.readConstraintValue(ub - lb + 1); err != nil {
+				return
+			}
+			length += lb
+		}
 		if isBitstring {
-			nbits = uint(c.Lb)
-			numBytes = (nbits + 7) >> 3
+			nbits = uint(length)
 		} else {
-			numBytes = uint(c.Lb)
-			nbits = numBytes * 8
+			nbits = uint(length) * 8
+		}
+		if nbits == 0 {
+			content = []byte{}
+			return
 		}
 		// UPER: no alignment check, read directly
 		content, err = ur.ReadBits(nbits)
@@ -183,10 +267,9 @@ func (ur *UperReader) ReadString(c *Constraint, e bool, isBitstring bool) (conte
 	var partLen uint64
 
 	for more {
-		if partLen, more, err = ur.readLength(lRange); err != nil {
+		if partLen, more, err = ur.readLength(0); err != nil {
 			return
 		}
-		partLen += uint64(lowerBound)
 		if partLen == 0 {
 			break
 		}
@@ -194,10 +277,10 @@ func (ur *UperReader) ReadString(c *Constraint, e bool, isBitstring bool) (conte
 		var partLenBits uint64
 		if isBitstring {
 			partLenBits = partLen
-			nbits += uint(partLen)
 		} else {
 			partLenBits = partLen * 8
 		}
+		nbits += uint(partLenBits)
 		if tmpBytes, err = ur.ReadBits(uint(partLenBits)); err != nil {
 			return
 		}
@@ -211,10 +294,14 @@ func (ur *UperReader) ReadString(c *Constraint, e bool, isBitstring bool) (conte
 }
 
 func (ur *UperReader) ReadBitString(c *Constraint, e bool) (content []byte, nbits uint, err error) {
+	return ur.ReadBitStringWith(NewPerConstraint(c, e))
+}
+
+func (ur *UperReader) ReadBitStringWith(pc *PerConstraint) (content []byte, nbits uint, err error) {
 	defer func() {
 		err = utils.WrapError("ReadBitString", err)
 	}()
-	content, nbits, err = ur.ReadString(c, e, true)
+	content, nbits, err = ur.ReadStringWith(pc, true)
 	if err != nil {
 		return
 	}
@@ -222,10 +309,14 @@ func (ur *UperReader) ReadBitString(c *Constraint, e bool) (content []byte, nbit
 }
 
 func (ur *UperReader) ReadOctetString(c *Constraint, e bool) (content []byte, err error) {
+	return ur.ReadOctetStringWith(NewPerConstraint(c, e))
+}
+
+func (ur *UperReader) ReadOctetStringWith(pc *PerConstraint) (content []byte, err error) {
 	defer func() {
 		err = utils.WrapError("ReadOctetString", err)
 	}()
-	content, _, err = ur.ReadString(c, e, false)
+	content, _, err = ur.ReadStringWith(pc, false)
 	if err != nil {
 		return
 	}
@@ -238,88 +329,76 @@ func (ur *UperReader) ReadOpenType() (octets []byte, err error) {
 	return
 }
 
-func (ur *UperReader) ReadInteger(c *Constraint, e bool) (value int64, err error) {
+// ReadOpenTypeValue reads an open type and decodes its octets into value.
+func (ur *UperReader) ReadOpenTypeValue(value UperUnmarshaller) (err error) {
 	defer func() {
-		err = utils.WrapError("ReadInteger", err)
+		err = utils.WrapError("ReadOpenTypeValue", err)
 	}()
-
-	sRange, _, err := ur.readExBit(c, e)
-	if err != nil {
-		return 0, err
+	var octets []byte
+	if octets, err = ur.ReadOpenType(); err != nil {
+		return
 	}
+	err = unmarshal(octets, value, ur.canonical)
+	return
+}
 
-	var rawLength uint
-	switch {
-	case sRange == 1:
-		value = c.Lb
-		return
+func (ur *UperReader) ReadInteger(c *Constraint, e bool) (value int64, err error) {
+	return ur.ReadIntegerWith(NewPerConstraint(c, e))
+}
 
-	case uint64(sRange) > 0 && uint64(sRange) <= POW_16:
-		// UPER: no alignment, read constrained value directly
-		var tmp uint64
-		if tmp, err = ur.readConstraintValue(uint64(sRange)); err != nil {
-			return
-		}
-		value = int64(tmp) + c.Lb
+// ReadIntegerWith decodes an INTEGER encoded by WriteIntegerWith with the same
+// constraint.
+func (ur *UperReader) ReadIntegerWith(pc *PerConstraint) (value int64, err error) {
+	defer func() {
+		err = utils.WrapError("ReadInteger", err)
+	}()
+	if err = pc.Validate(); err != nil {
 		return
-
-	case sRange == 0:
-		// UPER: unconstrained, no alignment, read length determinant (8 bits)
-		var lengthVal uint64
-		if lengthVal, err = ur.readValue(8); err != nil {
+	}
+	if pc.IsExtensible() {
+		var exBit bool
+		if exBit, err = ur.ReadBool(); err != nil {
 			return
 		}
-		rawLength = uint(lengthVal)
-
-	default: // sRange > POW_16, semi-constrained with large range
-		unsignedValueRange := uint64(sRange - 1)
-		var byteLen uint
-		for byteLen = 1; byteLen <= 127; byteLen++ {
-			unsignedValueRange >>= 8
-			if unsignedValueRange == 0 {
-				break
-			}
-		}
-		var bitLength, upper uint
-		for bitLength = 1; bitLength <= 8; bitLength++ {
-			upper = 1 << bitLength
-			if upper >= byteLen {
-				break
-			}
-		}
-		var tmp uint64
-		if tmp, err = ur.readValue(uint(bitLength)); err != nil {
+		if exBit { // Extension values are encoded as unconstrained whole numbers
+			value, err = ur.readUnconstrainedWholeNumber()
 			return
 		}
-		rawLength = uint(tmp) + 1
-		// UPER: no alignment
 	}
 
-	var rawValue uint64
-	if rawValue, err = ur.readValue(rawLength * 8); err != nil {
-		return
-	}
-
-	if sRange == 0 { // unconstrained
-		signedBitMask := uint64(1 << (rawLength*8 - 1))
-		valueMask := signedBitMask - 1
-		if rawValue&signedBitMask > 0 {
-			value = int64((^rawValue)&valueMask+1) * -1
-		} else {
-			value = int64(rawValue)
+	var tmp uint64
+	switch pc.Category() {
+	case Constrained:
+		if tmp, err = ur.readConstrainedWholeNumber(pc.Span()); err != nil {
+			return
+		}
+		value = int64(tmp + uint64(pc.Lb))
+	case SemiConstrained:
+		if tmp, err = ur.readSemiConstraintWholeNumber(0); err != nil {
+			return
 		}
-	} else { // with constraint
-		value = int64(rawValue) + c.Lb
+		value = int64(tmp + uint64(pc.Lb))
+	default:
+		value, err = ur.readUnconstrainedWholeNumber()
 	}
 	return
 }
 
 func (ur *UperReader) ReadEnumerate(c Constraint, e bool) (v uint64, err error) {
+	return ur.ReadEnumerateWith(NewPerConstraint(&c, e))
+}
+
+// ReadEnumerateWith decodes an enumeration index; pc must have both bounds.
+func (ur *UperReader) ReadEnumerateWith(pc *PerConstraint) (v uint64, err error) {
 	defer func() {
 		err = utils.WrapError("ReadEnumerate", err)
 	}()
+	if pc.Category() != Constrained || pc.Lb < 0 || pc.Validate() != nil {
+		err = ErrConstraint
+		return
+	}
 
-	if e {
+	if pc.Extensible {
 		var exBit bool
 		if exBit, err = ur.ReadBool(); err != nil {
 			return
@@ -329,19 +408,19 @@ func (ur *UperReader) ReadEnumerate(c Constraint, e bool) (v uint64, err error)
 			if tmp, err = ur.readNormallySmallNonNegativeValue(); err != nil {
 				return
 			}
-			v = tmp + uint64(c.Ub) + 1
+			v = tmp + uint64(pc.Ub) + 1
 			return
 		}
 	}
 
-	if c.Range() > 1 {
+	if pc.Range() > 1 {
 		var tmp uint64
-		if tmp, err = ur.readConstraintValue(c.Range()); err != nil {
+		if tmp, err = ur.readConstraintValue(pc.Range()); err != nil {
 			return
 		}
-		v = tmp + uint64(c.Lb)
+		v = tmp + uint64(pc.Lb)
 	} else {
-		v = uint64(c.Lb)
+		v = uint64(pc.Lb)
 	}
 	return
 }
@@ -426,29 +505,12 @@ func (ur *UperReader) ReadChoice(uBound uint64, e bool) (v uint64, err error) {
 
 	// Handle extension alternative
 	if isExtension {
-		// Read large index flag
-		var isLarge bool
-		if isLarge, err = ur.ReadBool(); err != nil {
+		// Index among the additions as a normally small non-negative number
+		var n uint64
+		if n, err = ur.readNormallySmallNonNegativeValue(); err != nil {
 			return
 		}
-
-		if !isLarge {
-			// Small extension index (≤63): read 6 bits
-			if idx, err = ur.readValue(6); err != nil {
-				return
-			}
-		} else {
-			// Large extension index (>63): read indefinite length octets
-			var hexValue []byte
-			// if hexValue, err = ur.readOctetsWithIndefiniteLength(); err != nil {
-			if hexValue, err = ur.ReadOpenType(); err != nil {
-				return
-			}
-			length := len(hexValue)
-			for i := 0; i < length; i++ {
-				idx = (idx << 8) | uint64(hexValue[i])
-			}
-		}
+		idx = uBound + n + 1
 	} else {
 		// Root alternative: read constrained value
 		if idx, err = ur.readConstraintValue(uBound + 1); err != nil {
@@ -460,6 +522,31 @@ func (ur *UperReader) ReadChoice(uBound uint64, e bool) (v uint64, err error) {
 	return
 }
 
+// ReadChoiceValue reads a CHOICE alternative and its value. alternative
+// returns the value to decode into for an index, or nil if the index is not
+// known. The open-type octets of an unknown extension alternative are
+// returned in raw; an unknown root alternative is an error.
+func (ur *UperReader) ReadChoiceValue(uBound uint64, e bool, alternative func(v uint64) UperUnmarshaller) (v uint64, raw []byte, err error) {
+	if v, err = ur.ReadChoice(uBound, e); err != nil {
+		return
+	}
+	value := alternative(v)
+	if v-1 <= uBound {
+		if value == nil {
+			err = utils.WrapError("ReadChoiceValue", ErrUnknownChoice)
+			return
+		}
+		err = value.Decode(ur)
+		return
+	}
+	if value == nil {
+		raw, err = ur.ReadOpenType()
+		return
+	}
+	err = ur.ReadOpenTypeValue(value)
+	return
+}
+
 // ReadBoolean decodes an ASN.1 BOOLEAN value according to UPER rules.
 // A BOOLEAN is decoded from a single bit: 1 for true, 0 for false.
 func (ur *UperReader) ReadBoolean() (value bool, err error) {
@@ -98,27 +98,7 @@ func TestPythonSchemaExample(t *testing.T) {
 
 // Helper to encode SEQUENCE OF INTEGER with size constraint
 func encodeSequenceOfIntegers(writer *UperWriter, values []int64, sizeConstraint *Constraint) error {
-	// Encode length: size - lowerBound in 3 bits (for range 1..8)
-	length := len(values)
-	lengthValue := uint64(length) - uint64(sizeConstraint.Lb)
-	sizeRange := sizeConstraint.Range() // should be 8
-
-	// Write length (3 bits for range 8)
-	numBits := 3 // log2(8) = 3
-	if err := writer.writeConstraintValue(sizeRange, lengthValue); err != nil {
-		return err
-	}
-
-	// Encode each INTEGER (unconstrained)
-	for _, val := range values {
-		if err := writer.WriteInteger(val, nil, false); err != nil {
-			return err
-		}
-	}
-
-	_ = numBits
-
-	return nil
+	return writer.WriteIntegerList(values, sizeConstraint, false, nil, false)
 }
 
 // Test individual components
@@ -0,0 +1,174 @@
+package uper
+
+import (
+	"github.com/lvdund/asn1go/internal/per"
+	"github.com/lvdund/asn1go/utils"
+)
+
+// SequenceField is a SEQUENCE component to encode. An OPTIONAL component, or
+// one with a DEFAULT value, is declared Optional and only encoded if Present.
+// A component with a DEFAULT value sets IsDefault when its value equals the
+// default; whether it is then left out is decided by the DefaultPolicy of the
+// encoder. See DefaultField.
+type SequenceField = per.SequenceField[*UperWriter]
+
+// DefaultPolicy tells an encoder what to do with a component whose value
+// equals its DEFAULT.
+type DefaultPolicy = per.DefaultPolicy
+
+const (
+	// DefaultCanonical leaves such a component out, as CANONICAL-PER requires.
+	DefaultCanonical = per.DefaultCanonical
+	// DefaultBasic encodes such a component if it is Present, which
+	// BASIC-PER allows.
+	DefaultBasic = per.DefaultBasic
+)
+
+// DefaultField declares a component holding v with the DEFAULT value def;
+// encode writes a value of the component.
+func DefaultField[T comparable](v, def T, encode func(uw *UperWriter, v T) error) SequenceField {
+	return DefaultFieldFunc(v, def, func(a, b T) bool { return a == b }, encode)
+}
+
+// DefaultFieldFunc is DefaultField for types whose values are compared by
+// equal, such as BIT STRING or OCTET STRING.
+func DefaultFieldFunc[T any](v, def T, equal func(a, b T) bool, encode func(uw *UperWriter, v T) error) SequenceField {
+	return per.DefaultFieldFunc(v, def, equal, encode)
+}
+
+// SequenceEncoder writes the machinery of a SEQUENCE around its components:
+// the extension bit, the presence preamble of the optional root components,
+// the extension bitmap and the open-type wrapping of the extension additions.
+//
+// Each entry of Extensions is an extension addition group [[ ... ]]; the
+// group is present if any of its components is Present, and its mandatory
+// components are then encoded too. An addition outside [[ ]] is declared as a
+// group of one mandatory component. Raw holds the unknown additions kept by a
+// SequenceDecoder, which are written back unchanged. Defaults is the policy
+// for components equal to their DEFAULT value.
+type SequenceEncoder struct {
+	Extensible bool
+	Root       []SequenceField
+	Extensions [][]SequenceField
+	Raw        *RawExtensions
+	Defaults   DefaultPolicy
+}
+
+// Encode writes the SEQUENCE.
+func (se *SequenceEncoder) Encode(uw *UperWriter) (err error) {
+	defer func() {
+		err = utils.WrapError("SequenceEncoder", err)
+	}()
+	policy := se.Defaults
+	if uw.canonical {
+		policy = DefaultCanonical
+	}
+	root := per.ApplyDefaults(se.Root, policy)
+	extensions := make([][]SequenceField, len(se.Extensions))
+	extended := !se.Raw.IsEmpty()
+	for i, group := range se.Extensions {
+		extensions[i] = per.ApplyDefaults(group, policy)
+		extended = extended || per.GroupPresent(extensions[i])
+	}
+	var additions []UperMarshaller
+	if extended {
+		if !se.Extensible {
+			err = ErrInextensible
+			return
+		}
+		additions = make([]UperMarshaller, len(extensions))
+		for i, group := range extensions {
+			if per.GroupPresent(group) {
+				additions[i] = fieldsEncoder(group)
+			}
+		}
+	}
+
+	if se.Extensible {
+		if err = uw.WriteBool(extended); err != nil {
+			return
+		}
+	}
+	if err = fieldsEncoder(root).Encode(uw); err != nil {
+		return
+	}
+	if extended {
+		err = uw.WriteExtensionAdditions(additions, se.Raw)
+	}
+	return
+}
+
+// fieldsEncoder encodes a list of components after their presence preamble.
+type fieldsEncoder []SequenceField
+
+func (fields fieldsEncoder) Encode(uw *UperWriter) error {
+	return per.EncodeFields(uw, fields)
+}
+
+// SequenceFieldDecoder is a SEQUENCE component to decode. Decode is only
+// called if the component is present; otherwise Default, if set, fills in the
+// DEFAULT value of the component. IsDefault, if set, reports whether the
+// decoded value equals the DEFAULT value, which a canonical reader rejects.
+// See DefaultFieldDecoder.
+type SequenceFieldDecoder = per.SequenceFieldDecoder[*UperReader]
+
+// DefaultFieldDecoder declares a component with the DEFAULT value def, decoded
+// into v by decode.
+func DefaultFieldDecoder[T comparable](v *T, def T, decode func(ur *UperReader) (T, error)) SequenceFieldDecoder {
+	return DefaultFieldDecoderFunc(v, def, func(a, b T) bool { return a == b }, decode)
+}
+
+// DefaultFieldDecoderFunc is DefaultFieldDecoder for types whose values are
+// compared by equal.
+func DefaultFieldDecoderFunc[T any](v *T, def T, equal func(a, b T) bool, decode func(ur *UperReader) (T, error)) SequenceFieldDecoder {
+	return per.DefaultFieldDecoderFunc(v, def, equal, decode)
+}
+
+// SequenceDecoder reads a SEQUENCE written by a SequenceEncoder with the same
+// declarations. Extension additions unknown to the decoder are kept in Raw;
+// passing them to the SequenceEncoder re-encodes the value unchanged.
+type SequenceDecoder struct {
+	Extensible bool
+	Root       []SequenceFieldDecoder
+	Extensions [][]SequenceFieldDecoder
+	Raw        RawExtensions
+}
+
+// Decode reads the SEQUENCE.
+func (sd *SequenceDecoder) Decode(ur *UperReader) (err error) {
+	defer func() {
+		err = utils.WrapError("SequenceDecoder", err)
+	}()
+	var extended bool
+	if sd.Extensible {
+		if extended, err = ur.ReadBool(); err != nil {
+			return
+		}
+	}
+	if err = fieldsDecoder(sd.Root).Decode(ur); err != nil {
+		return
+	}
+	var present []bool
+	if extended {
+		additions := make([]UperUnmarshaller, len(sd.Extensions))
+		for i, group := range sd.Extensions {
+			additions[i] = fieldsDecoder(group)
+		}
+		if present, sd.Raw, err = ur.ReadExtensionAdditions(additions); err != nil {
+			return
+		}
+	}
+	for i, group := range sd.Extensions { //absent additions take their DEFAULT values
+		if i >= len(present) || !present[i] {
+			per.SetDefaults(group)
+		}
+	}
+	return
+}
+
+// fieldsDecoder decodes a list of components after their presence preamble.
+type fieldsDecoder []SequenceFieldDecoder
+
+func (fields fieldsDecoder) Decode(ur *UperReader) error {
+	return per.DecodeFields(ur, fields)
+}
@@ -1,120 +1,168 @@
 package uper
 
 import (
-	"fmt"
+	"iter"
 
 	"github.com/lvdund/asn1go/utils"
 )
 
 func WriteSequenceOf[T UperMarshaller](items []T, uw *UperWriter, c *Constraint, e bool) (err error) {
+	return WriteSequenceOfWith(items, uw, NewPerConstraint(c, e))
+}
+
+// WriteSequenceOfWith encodes a SEQUENCE OF whose size is constrained by pc.
+func WriteSequenceOfWith[T UperMarshaller](items []T, uw *UperWriter, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteSequenceOf", err)
 	}()
+	err = uw.writeSequenceOf(len(items), pc, func(i int) error { return items[i].Encode(uw) })
+	return
+}
 
-	numElems := len(items)
-
-	var lowerBound, sizeRange uint64 = 0, 0
-	if c != nil {
-		if c.Lb < 0 || uint64(c.Lb) >= POW_16 {
-			err = ErrConstraint
-			return
-		}
-		lowerBound = uint64(c.Lb)
-		sizeRange = c.Range()
-		// UPER: no special handling for large upper bounds
-	}
+// writeSequenceOf writes the count of a SEQUENCE OF with n elements whose size
+// is constrained by pc, calling encode for every element in turn.
+func (uw *UperWriter) writeSequenceOf(n int, pc *PerConstraint, encode func(i int) error) (err error) {
+	numElems := uint64(n)
 
-	if uint64(numElems) < lowerBound {
-		err = ErrUnderflow
+	lowerBound, upperBound, hasUb, err := pc.SizeBounds()
+	if err != nil {
 		return
 	}
 
-	if e {
-		if sizeRange == 0 {
+	inRoot := numElems >= lowerBound && (!hasUb || numElems <= upperBound)
+	if !inRoot && !pc.IsExtensible() {
+		if numElems < lowerBound { //too few items
+			err = ErrUnderflow
+		} else {
 			err = ErrInextensible
-			return
 		}
-		if err = uw.WriteBool(int64(numElems) > c.Ub); err != nil {
+		return
+	}
+	if pc.IsExtensible() {
+		//write extension bit
+		if err = uw.WriteBool(!inRoot); err != nil {
 			return
 		}
 	}
+	if !inRoot { //outside the extension root the count is semi-constrained
+		lowerBound, hasUb = 0, false
+	}
 
-	if sizeRange > 1 {
-		if err = uw.writeConstraintValue(sizeRange, uint64(numElems)-lowerBound); err != nil {
-			return
-		}
-	} else if sizeRange == 0 {
-		// UPER: no alignment
-		if err = uw.writeValue(uint64(numElems&0xff), 8); err != nil {
-			return
+	//NOTE: if size range is 1, no need to write sequence size
+	constrained := hasUb && upperBound < POW_16
+	if constrained {
+		if sizeRange := upperBound - lowerBound + 1; sizeRange > 1 {
+			if err = uw.writeConstraintValue(sizeRange, numElems-lowerBound); err != nil {
+				return
+			}
 		}
 	}
-
-	for _, item := range items {
-		if err = item.Encode(uw); err != nil {
-			return
+	//otherwise the count is a length determinant, in 16K fragments for long lists
+	for start, last := uint64(0), false; !last; {
+		part := numElems - start
+		last = true
+		if !constrained {
+			part, last = fragmentLength(part)
+			if err = uw.writeLength(0, part); err != nil {
+				return
+			}
+		}
+		for i := start; i < start+part; i++ {
+			if err = encode(int(i)); err != nil {
+				return
+			}
 		}
+		start += part
 	}
-
-	err = uw.flush()
 	return
 }
 
 func ReadSequenceOf[T any](decoder func(ur *UperReader) (*T, error), ur *UperReader, c *Constraint, e bool) (items []T, err error) {
-	var lowerBound, sizeRange uint64 = 0, 0
-	if c != nil {
-		if c.Lb < 0 || uint64(c.Lb) >= POW_16 {
-			err = ErrConstraint
-			return
-		}
-		lowerBound = uint64(c.Lb)
-		sizeRange = c.Range()
-	}
+	return ReadSequenceOfWith(decoder, ur, NewPerConstraint(c, e))
+}
 
-	var exBit bool
-	if e {
-		if sizeRange == 0 {
-			err = ErrInextensible
-			return
+// ReadSequenceOfWith decodes a SEQUENCE OF whose size is constrained by pc.
+func ReadSequenceOfWith[T any](decoder func(ur *UperReader) (*T, error), ur *UperReader, pc *PerConstraint) (items []T, err error) {
+	err = ur.readSequenceOf(pc, func() error {
+		item, err := decoder(ur)
+		if err != nil {
+			return err
 		}
+		items = append(items, *item)
+		return nil
+	})
+	return
+}
 
+// readSequenceOf reads the count of a SEQUENCE OF whose size is constrained by
+// pc, calling decode for every element in turn.
+func (ur *UperReader) readSequenceOf(pc *PerConstraint, decode func() error) (err error) {
+	lowerBound, upperBound, hasUb, err := pc.SizeBounds()
+	if err != nil {
+		return
+	}
+
+	if pc.IsExtensible() {
+		var exBit bool
 		if exBit, err = ur.ReadBool(); err != nil {
 			return
 		}
+		if exBit { //outside the extension root the count is semi-constrained
+			lowerBound, hasUb = 0, false
+		}
 	}
 
+	//3. read num elements, in fragments if the count is a length determinant
 	var numElems uint64
-	if sizeRange == 1 {
+	constrained := hasUb && upperBound < POW_16
+	if constrained {
 		numElems = lowerBound
-	} else if sizeRange > 1 {
-		if numElems, err = ur.readConstraintValue(sizeRange); err != nil {
-			return
+		if sizeRange := upperBound - lowerBound + 1; sizeRange > 1 {
+			if numElems, err = ur.readConstraintValue(sizeRange); err != nil {
+				return
+			}
+			numElems += lowerBound
 		}
-		numElems += lowerBound
-		if exBit && numElems <= uint64(c.Ub) {
-			err = fmt.Errorf("Inconsistent extension bit")
-			return
+	}
+	//4. read every element, holding a length-determinant count to the root bounds
+	for more, total := true, uint64(0); more; {
+		count := numElems
+		more = false
+		if !constrained {
+			if count, more, err = ur.readLength(0); err != nil {
+				return
+			}
+			if total += count; hasUb && total > upperBound {
+				err = ErrInextensible
+				return
+			}
+			if !more && total < lowerBound {
+				err = ErrUnderflow
+				return
+			}
 		}
-	} else {
-		// UPER: no alignment
-		if numElems, err = ur.readValue(8); err != nil {
-			return
+		for i := uint64(0); i < count; i++ {
+			if err = decode(); err != nil {
+				return
+			}
 		}
 	}
+	return
+}
 
-	items = make([]T, numElems)
-	var tmpItem *T
-	for i := 0; i < int(numElems); i++ {
-		if tmpItem, err = decoder(ur); err != nil {
-			fmt.Println("\terr", err)
-			return
+func ReadSequenceOfEx[T UperUnmarshaller](fn func() T, ur *UperReader, c *Constraint, e bool) (items []T, err error) {
+	decoder := func(ur *UperReader) (*T, error) {
+		item := fn()
+		if err := item.Decode(ur); err != nil {
+			return nil, err
 		}
-		items[i] = *tmpItem
+		return &item, nil
 	}
+	items, err = ReadSequenceOf[T](decoder, ur, c, e)
 	return
 }
 
-func ReadSequenceOfEx[T UperUnmarshaller](fn func() T, ur *UperReader, c *Constraint, e bool) (items []T, err error) {
+func ReadSequenceOfExWith[T UperUnmarshaller](fn func() T, ur *UperReader, pc *PerConstraint) (items []T, err error) {
 	decoder := func(ur *UperReader) (*T, error) {
 		item := fn()
 		if err := item.Decode(ur); err != nil {
@@ -122,25 +170,60 @@ func ReadSequenceOfEx[T UperUnmarshaller](fn func() T, ur *UperReader, c *Constr
 		}
 		return &item, nil
 	}
-	items, err = ReadSequenceOf[T](decoder, ur, c, e)
+	items, err = ReadSequenceOfWith[T](decoder, ur, pc)
 	return
 }
 
+// ReadSequenceOfSeq decodes a SEQUENCE OF one element per iteration, so that
+// a long list need not be held in memory. A decoding error is yielded once
+// and ends the iteration. Breaking out of the loop stops decoding there,
+// leaving ur inside the SEQUENCE OF with no way to skip the remaining
+// elements, so it must not be read further. The sequence reads from ur and
+// can be ranged over once.
+func ReadSequenceOfSeq[T any](decoder func(ur *UperReader) (*T, error), ur *UperReader, c *Constraint, e bool) iter.Seq2[T, error] {
+	return ReadSequenceOfSeqWith(decoder, ur, NewPerConstraint(c, e))
+}
+
+// ReadSequenceOfSeqWith decodes a SEQUENCE OF whose size is constrained by pc
+// one element per iteration.
+func ReadSequenceOfSeqWith[T any](decoder func(ur *UperReader) (*T, error), ur *UperReader, pc *PerConstraint) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		stopped := false
+		err := ur.readSequenceOf(pc, func() error {
+			item, err := decoder(ur)
+			if err != nil {
+				return err
+			}
+			if !yield(*item, nil) {
+				stopped = true
+				return errStopIteration
+			}
+			return nil
+		})
+		if err != nil && !stopped {
+			var zero T
+			yield(zero, utils.WrapError("ReadSequenceOf", err))
+		}
+	}
+}
+
 type ListContainer[T UperMarshaller] struct {
 	list []T
-	e    bool
-	c    *Constraint
+	pc   *PerConstraint
 }
 
 func NewListContainer[T UperMarshaller](list []T, c *Constraint, e bool) ListContainer[T] {
+	return NewListContainerWith(list, NewPerConstraint(c, e))
+}
+
+func NewListContainerWith[T UperMarshaller](list []T, pc *PerConstraint) ListContainer[T] {
 	return ListContainer[T]{
 		list: list,
-		e:    e,
-		c:    c,
+		pc:   pc,
 	}
 }
 
 func (l ListContainer[T]) Encode(uw *UperWriter) (err error) {
-	err = WriteSequenceOf[T](l.list, uw, l.c, l.e)
+	err = WriteSequenceOfWith[T](l.list, uw, l.pc)
 	return
 }
@@ -0,0 +1,125 @@
+package uper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestWriteSetOf_Canonical(t *testing.T) {
+	c := &Constraint{Lb: 0, Ub: 8}
+	items := []*ConstrainedOctetString{{C: c, Value: []byte("b")}, {C: c, Value: []byte("ab")}, {C: c, Value: []byte("a")}}
+	for _, canonical := range []bool{false, true} {
+		var buf bytes.Buffer
+		uw := NewWriter(&buf)
+		uw.SetCanonical(canonical)
+		if err := WriteSetOf(items, uw, nil, false); err != nil {
+			t.Fatalf("WriteSetOf() error = %v", err)
+		}
+		if err := uw.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		got, err := ReadSequenceOfEx(func() *ConstrainedOctetString { return &ConstrainedOctetString{C: c} }, NewReader(bytes.NewReader(buf.Bytes())), nil, false)
+		if err != nil {
+			t.Fatalf("ReadSequenceOfEx() error = %v", err)
+		}
+		want := []string{"b", "ab", "a"}
+		if canonical {
+			want = []string{"a", "b", "ab"}
+		}
+		// the 4-bit lengths leave the contents unaligned: a, b, ab
+		if encoded := hex.EncodeToString(buf.Bytes()); canonical && encoded != "03161162261620" {
+			t.Errorf("WriteSetOf() = %s, want 03161162261620", encoded)
+		}
+		for i := range got {
+			if string(got[i].Value) != want[i] {
+				t.Errorf("canonical=%v: element %d = %q, want %q", canonical, i, got[i].Value, want[i])
+			}
+		}
+	}
+	if string(items[0].Value) != "b" {
+		t.Error("WriteSetOf() reordered its input")
+	}
+}
+
+// SET { b [1] BOOLEAN, a [0] INTEGER (0..7) } is encoded in tag order: a, b
+func TestSetEncoder(t *testing.T) {
+	c := &Constraint{Lb: 0, Ub: 7}
+	se := SetEncoder{Root: []SetField{
+		{Tag{TagContextSpecific, 1}, SequenceField{Encode: func(uw *UperWriter) error { return uw.WriteBool(true) }}},
+		{Tag{TagContextSpecific, 0}, SequenceField{Encode: func(uw *UperWriter) error { return uw.WriteInteger(5, c, false) }}},
+	}}
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	if err := se.Encode(uw); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := hex.EncodeToString(buf.Bytes()); got != "b0" {
+		t.Errorf("Encode() = %s, want b0", got)
+	}
+
+	var a int64
+	var b bool
+	sd := SetDecoder{Root: []SetFieldDecoder{
+		{Tag{TagContextSpecific, 1}, SequenceFieldDecoder{Decode: func(ur *UperReader) (err error) {
+			b, err = ur.ReadBool()
+			return
+		}}},
+		{Tag{TagContextSpecific, 0}, SequenceFieldDecoder{Decode: func(ur *UperReader) (err error) {
+			a, err = ur.ReadInteger(c, false)
+			return
+		}}},
+	}}
+	if err := sd.Decode(NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if a != 5 || !b {
+		t.Errorf("Decode() = %d, %v, want 5, true", a, b)
+	}
+}
+
+// testOctets is a SET OF element written as its octets alone, so that two
+// elements may differ by trailing 0 octets only
+type testOctets []byte
+
+func (o *testOctets) Encode(uw *UperWriter) error {
+	return uw.WriteBits(*o, uint(8*len(*o)))
+}
+
+func TestSetOf_TrailingZeroKeys(t *testing.T) {
+	// the keys 0100 and 01 are equal once 01 is extended with a 0 octet, so
+	// both orders are canonical
+	items := []*testOctets{{0x01, 0x00}, {0x01}}
+	var buf bytes.Buffer
+	uw := NewWriter(&buf)
+	uw.SetCanonical(true)
+	if err := WriteSetOf(items, uw, nil, false); err != nil {
+		t.Fatalf("WriteSetOf() error = %v", err)
+	}
+	if err := uw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := hex.EncodeToString(buf.Bytes()); got != "02010001" {
+		t.Errorf("WriteSetOf() = %s, want 02010001", got)
+	}
+
+	lengths := []uint{2, 1}
+	decode := func(ur *UperReader) (*testOctets, error) {
+		b, err := ur.readBytes(lengths[0])
+		lengths = lengths[1:]
+		v := testOctets(b)
+		return &v, err
+	}
+	ur := NewReader(bytes.NewReader(buf.Bytes()))
+	ur.SetCanonical(true)
+	got, err := ReadSetOf(decode, ur, nil, false)
+	if err != nil {
+		t.Fatalf("ReadSetOf() error = %v", err)
+	}
+	if len(got) != 2 || hex.EncodeToString(got[0]) != "0100" || hex.EncodeToString(got[1]) != "01" {
+		t.Errorf("ReadSetOf() = %x", got)
+	}
+}
@@ -0,0 +1,76 @@
+package uper
+
+// ConstrainedInteger, ConstrainedEnumerated, ConstrainedOctetString and
+// ConstrainedBitString are the built-in types bundled with their constraint so
+// that they implement IE and can be used as list elements, e.g.
+// SEQUENCE (SIZE(1..8)) OF OCTET STRING (SIZE(4)):
+//
+//	items := []*ConstrainedOctetString{{C: &Constraint{Lb: 4, Ub: 4}, Value: v}}
+//	WriteSequenceOf(items, uw, &Constraint{Lb: 1, Ub: 8}, false)
+//	ReadSequenceOfEx(func() *ConstrainedOctetString {
+//		return &ConstrainedOctetString{C: &Constraint{Lb: 4, Ub: 4}}
+//	}, ur, &Constraint{Lb: 1, Ub: 8}, false)
+//
+// A nil C means no constraint, except for ConstrainedEnumerated which needs
+// one; Ext marks the constraint as extensible.
+
+type ConstrainedInteger struct {
+	C     *Constraint
+	Ext   bool
+	Value int64
+}
+
+func (v *ConstrainedInteger) Encode(uw *UperWriter) error {
+	return uw.WriteInteger(v.Value, v.C, v.Ext)
+}
+
+func (v *ConstrainedInteger) Decode(ur *UperReader) (err error) {
+	v.Value, err = ur.ReadInteger(v.C, v.Ext)
+	return
+}
+
+// ConstrainedEnumerated holds an enumeration index; C must bound the root
+// indexes.
+type ConstrainedEnumerated struct {
+	C     *Constraint
+	Ext   bool
+	Value uint64
+}
+
+func (v *ConstrainedEnumerated) Encode(uw *UperWriter) error {
+	return uw.WriteEnumerateWith(v.Value, NewPerConstraint(v.C, v.Ext))
+}
+
+func (v *ConstrainedEnumerated) Decode(ur *UperReader) (err error) {
+	v.Value, err = ur.ReadEnumerateWith(NewPerConstraint(v.C, v.Ext))
+	return
+}
+
+type ConstrainedOctetString struct {
+	C     *Constraint
+	Ext   bool
+	Value OctetString
+}
+
+func (v *ConstrainedOctetString) Encode(uw *UperWriter) error {
+	return uw.WriteOctetString(v.Value, v.C, v.Ext)
+}
+
+func (v *ConstrainedOctetString) Decode(ur *UperReader) (err error) {
+	v.Value, err = ur.ReadOctetString(v.C, v.Ext)
+	return
+}
+
+type ConstrainedBitString struct {
+	C     *Constraint
+	Ext   bool
+	Value BitString
+}
+
+func (v *ConstrainedBitString) Encode(uw *UperWriter) error {
+	return v.Value.Encode(uw, NewPerConstraint(v.C, v.Ext))
+}
+
+func (v *ConstrainedBitString) Decode(ur *UperReader) error {
+	return v.Value.Decode(ur, NewPerConstraint(v.C, v.Ext))
+}
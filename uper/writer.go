@@ -12,6 +12,7 @@ import (
 
 type UperWriter struct {
 	*bitstreamWriter
+	canonical bool
 }
 
 func NewWriter(w io.Writer) *UperWriter {
@@ -20,6 +21,21 @@ func NewWriter(w io.Writer) *UperWriter {
 	}
 }
 
+// SetCanonical switches the writer to CANONICAL-PER (X.691 clause 6.3):
+// components equal to their DEFAULT value are left out and the elements of a
+// SET OF are sorted, in the values written as open types or contained
+// encodings too. The other encodings of the writer are canonical in any mode:
+// whole numbers take their minimal number of octets and named bit strings
+// are trimmed.
+func (uw *UperWriter) SetCanonical(canonical bool) {
+	uw.canonical = canonical
+}
+
+// Canonical reports whether the writer encodes with CANONICAL-PER.
+func (uw *UperWriter) Canonical() bool {
+	return uw.canonical
+}
+
 func (uw *UperWriter) Close() error {
 	if err := uw.flush(); err != nil {
 		return err
@@ -66,10 +82,10 @@ func (uw *UperWriter) writeSemiConstraintWholeNumber(v uint64, lb uint64) (err e
 		return
 	}
 	v -= lb
-	length := (bits.Len64(v) + 7) >> 3
+	length := octetsOf(v)
 
-	// UPER: no alignment, write length then value
-	if err = uw.writeValue(uint64(length), 8); err != nil {
+	// UPER: no alignment, an unconstrained length then the minimal value octets
+	if err = uw.writeLength(0, uint64(length)); err != nil {
 		return
 	}
 	err = uw.writeValue(v, uint(length)*8)
@@ -122,69 +138,122 @@ func (uw *UperWriter) writeLength(r uint64, v uint64) (err error) {
 	return
 }
 
-// writeConstraintValue for UPER (no alignment for small values)
+// writeConstraintValue writes v, one of a range of r values, as a bit-field of
+// the minimal width that holds r-1, without alignment (X.691 clause 11.5.6):
+// e.g. 10 bits for a range of 1001 values and 17 bits for 65537 values.
 func (uw *UperWriter) writeConstraintValue(r uint64, v uint64) (err error) {
 	defer func() {
 		err = utils.WrapError("writeConstraintValue", err)
 	}()
 
-	if r < POW_8 {
-		// UPER: write value bits directly, no alignment
-		return uw.writeValue(v, uint(bits.Len64(r-1)))
-	} else if r == POW_8 {
-		// UPER: no alignment, just write 8 bits
-		return uw.writeValue(v, 8)
-	} else if r <= POW_16 {
-		// UPER: no alignment, write 16 bits
-		return uw.writeValue(v, 16)
-	} else {
+	if r == 0 || v >= r {
 		return ErrOverflow
 	}
+	return uw.writeValue(v, uint(bits.Len64(r-1)))
+}
+
+// writeConstrainedWholeNumber encodes the offset v-lb of a value whose range
+// is span+1. Unlike the ALIGNED variant, UPER never aligns nor adds a length
+// for a large range: the offset is a bit-field of the minimal width that
+// holds span (X.691 clause 11.5.6), e.g. 10 bits for 0..1000, 32 bits for
+// 0..2^32-1 and 41 bits for 0..2^40.
+func (uw *UperWriter) writeConstrainedWholeNumber(v uint64, span uint64) (err error) {
+	defer func() {
+		err = utils.WrapError("writeConstrainedWholeNumber", err)
+	}()
+
+	if span == 0 {
+		return
+	}
+	if v > span {
+		err = ErrOverflow
+		return
+	}
+	err = uw.writeValue(v, uint(bits.Len64(span)))
+	return
+}
+
+// writeUnconstrainedWholeNumber encodes v as a length determinant followed by
+// the minimal two's-complement octets
+func (uw *UperWriter) writeUnconstrainedWholeNumber(v int64) (err error) {
+	defer func() {
+		err = utils.WrapError("writeUnconstrainedWholeNumber", err)
+	}()
+
+	length := signedOctetsOf(v)
+	if err = uw.writeLength(0, uint64(length)); err != nil {
+		return
+	}
+	err = uw.writeValue(uint64(v), length*8)
+	return
 }
 
+// WriteString is the legacy form of WriteStringWith.
 func (uw *UperWriter) WriteString(content []byte, len uint64, c *Constraint, e bool, isBitstring bool) (err error) {
-	lowerBound, lRange, _ := uw.writeExtBit(len, e, c)
+	return uw.WriteStringWith(content, len, NewPerConstraint(c, e), isBitstring)
+}
 
-	if lRange == 1 {
-		if int64(len) != lowerBound {
-			err = ErrFixedLength
+// WriteStringWith encodes a BIT STRING (isBitstring, len in bits) or an OCTET
+// STRING (len in octets) whose size is constrained by pc.
+func (uw *UperWriter) WriteStringWith(content []byte, len uint64, pc *PerConstraint, isBitstring bool) (err error) {
+	lb, ub, hasUb, err := pc.SizeBounds()
+	if err != nil {
+		return
+	}
+	inRoot := len >= lb && (!hasUb || len <= ub)
+	if pc.IsExtensible() {
+		if err = uw.WriteBool(!inRoot); err != nil {
+			return
+		}
+	}
+	if !inRoot {
+		if !pc.IsExtensible() {
+			if hasUb && lb == ub {
+				err = ErrFixedLength
+			} else {
+				err = ErrInextensible
+			}
 			return
 		}
+		// Outside the extension root the size is semi-constrained
+		lb, hasUb = 0, false
+	}
+
+	if hasUb && ub < POW_16 {
 		var nbits uint64
 		if isBitstring {
 			nbits = len
 		} else {
 			nbits = len * 8
 		}
+		if lb != ub {
+			// Length is a constrained whole number
+			if err = uw.writeConstraintValue(ub-lb+1, len-lb); err != nil {
+				return
+			}
+		}
 		// UPER: no alignment check for small values, write directly
 		err = uw.WriteBits(content, uint(nbits))
 		return
 	}
 
+	// Semi-constrained or unconstrained length, fragmented in 16K blocks
 	partReader := NewBitStreamReader(bytes.NewReader(content))
-	totalLen := uint64(len) - uint64(lowerBound)
+	totalLen := len
 	var partLen uint64
 	var partBytes []byte
 	completed := false
 
 	for {
-		if totalLen > POW_16 {
-			partLen = POW_16
-		} else if totalLen >= POW_14 {
-			partLen = totalLen & 0xc000
-		} else {
-			partLen = totalLen
-			completed = true
-		}
+		partLen, completed = fragmentLength(totalLen)
 		totalLen -= partLen
 
 		// Encode length
-		if err = uw.writeLength(uint64(lRange), partLen); err != nil {
+		if err = uw.writeLength(0, partLen); err != nil {
 			return
 		}
 
 		// Write content part (UPER: no alignment)
-		partLen += uint64(lowerBound)
 		if partLen == 0 {
 			return
 		}
@@ -209,40 +278,58 @@ func (uw *UperWriter) WriteString(content []byte, len uint64, c *Constraint, e b
 }
 
 func (uw *UperWriter) WriteBitString(content []byte, nbits uint, c *Constraint, e bool) (err error) {
+	return uw.WriteBitStringWith(content, nbits, NewPerConstraint(c, e))
+}
+
+func (uw *UperWriter) WriteBitStringWith(content []byte, nbits uint, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteBitString", err)
 	}()
-	err = uw.WriteString(content, uint64(nbits), c, e, true)
+	err = uw.WriteStringWith(content, uint64(nbits), pc, true)
 	return
 }
 
 func (uw *UperWriter) WriteOctetString(content []byte, c *Constraint, e bool) (err error) {
+	return uw.WriteOctetStringWith(content, NewPerConstraint(c, e))
+}
+
+func (uw *UperWriter) WriteOctetStringWith(content []byte, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteOctetString", err)
 	}()
 	byteLen := uint64(len(content))
-	err = uw.WriteString(content, byteLen, c, e, false)
+	err = uw.WriteStringWith(content, byteLen, pc, false)
 	return
 }
 
 func (uw *UperWriter) WriteEnumerate(v uint64, c Constraint, e bool) (err error) {
+	return uw.WriteEnumerateWith(v, NewPerConstraint(&c, e))
+}
+
+// WriteEnumerateWith encodes an enumeration index; pc must have both bounds.
+// Indexes above the root upper bound are extension additions.
+func (uw *UperWriter) WriteEnumerateWith(v uint64, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteEnumerate", err)
 	}()
+	if pc.Category() != Constrained || pc.Lb < 0 || pc.Validate() != nil {
+		err = ErrConstraint
+		return
+	}
 
-	if v <= uint64(c.Ub) {
-		if e {
+	if v <= uint64(pc.Ub) {
+		if pc.Extensible {
 			if err = uw.WriteBool(Zero); err != nil {
 				return
 			}
 		}
-		vRange := c.Range()
+		vRange := pc.Range()
 		if vRange > 1 {
-			err = uw.writeConstraintValue(vRange, v-uint64(c.Lb))
+			err = uw.writeConstraintValue(vRange, v-uint64(pc.Lb))
 			return
 		}
 	} else {
-		if !e {
+		if !pc.Extensible {
 			err = ErrInextensible
 			return
 		}
@@ -250,7 +337,7 @@ func (uw *UperWriter) WriteEnumerate(v uint64, c Constraint, e bool) (err error)
 		if err = uw.WriteBool(One); err != nil {
 			return
 		}
-		err = uw.writeNormallySmallNonNegativeValue(v - uint64(c.Ub) - 1)
+		err = uw.writeNormallySmallNonNegativeValue(v - uint64(pc.Ub) - 1)
 	}
 
 	return
@@ -264,83 +351,58 @@ func (uw *UperWriter) WriteOpenType(content []byte) (err error) {
 	return
 }
 
+// WriteOpenTypeValue encodes value on its own and writes the complete
+// encoding as an open type.
+func (uw *UperWriter) WriteOpenTypeValue(value UperMarshaller) (err error) {
+	defer func() {
+		err = utils.WrapError("WriteOpenTypeValue", err)
+	}()
+	var content []byte
+	if content, err = marshal(value, uw.canonical); err != nil {
+		return
+	}
+	err = uw.WriteOpenType(content)
+	return
+}
+
 func (uw *UperWriter) WriteInteger(v int64, c *Constraint, e bool) (err error) {
+	return uw.WriteIntegerWith(v, NewPerConstraint(c, e))
+}
+
+// WriteIntegerWith encodes an INTEGER as a constrained, semi-constrained or
+// unconstrained whole number depending on the category of pc.
+func (uw *UperWriter) WriteIntegerWith(v int64, pc *PerConstraint) (err error) {
 	defer func() {
 		err = utils.WrapError("WriteInteger", err)
 	}()
-	lb, sRange, _ := uw.writeExtBit(uint64(v), e, c)
-
-	if sRange == 1 {
-		return nil
+	if err = pc.Validate(); err != nil {
+		return
 	}
-
-	if sRange > 0 && sRange <= 65536 {
-		// UPER: write constrained value directly (no alignment)
-		return uw.writeConstraintValue(uint64(sRange), uint64(v-lb))
+	inRoot := pc.InRoot(v)
+	if !inRoot && !pc.InExtension(v) {
+		err = ErrInextensible
+		return
 	}
-
-	// For unconstrained or semi-constrained integers
-	// Calculate length based on the actual value (not shifted)
-	var rawLength uint
-	if v == 0 {
-		rawLength = 1
-	} else if v < 0 {
-		// For negative values, find minimum bytes needed
-		tempVal := v
-		for rawLength = 1; rawLength <= 127; rawLength++ {
-			if tempVal >= -128 && tempVal < 0 {
-				break
-			}
-			tempVal >>= 8
-		}
-		// Ensure sign bit is set
-		if (v & (1 << (8*rawLength - 1))) == 0 {
-			rawLength++
-		}
-	} else {
-		// For positive values, find minimum bytes needed
-		tempVal := v
-		for rawLength = 1; rawLength <= 127; rawLength++ {
-			if tempVal < 256 {
-				break
-			}
-			tempVal >>= 8
-		}
-		// Ensure sign bit is NOT set (for unsigned representation)
-		if sRange <= 0 && (v&(1<<(8*rawLength-1))) != 0 {
-			rawLength++
+	if pc.IsExtensible() {
+		if err = uw.WriteBool(!inRoot); err != nil {
+			return
 		}
 	}
-
-	// UPER: no alignment, write length determinant
-	if sRange <= 0 {
-		// Unconstrained: write length in 8 bits
-		if err := uw.writeValue(uint64(rawLength), 8); err != nil {
-			return err
-		}
-	} else {
-		// Semi-constrained with large range
-		unsignedValueRange := uint64(sRange - 1)
-		bitLen := bits.Len64(unsignedValueRange)
-		byteLen := uint((bitLen + 7) / 8)
-
-		var bitLength int
-		if byteLen == 0 {
-			bitLength = 0
-		} else if byteLen&(byteLen-1) == 0 {
-			bitLength = bits.Len(uint(byteLen)) - 1
-		} else {
-			bitLength = bits.Len(uint(byteLen))
-		}
-
-		if err := uw.writeValue(uint64(rawLength-1), uint(bitLength)); err != nil {
-			return err
-		}
+	if !inRoot {
+		// Extension values are encoded as unconstrained whole numbers
+		err = uw.writeUnconstrainedWholeNumber(v)
+		return
 	}
 
-	// UPER: no alignment before value, write as two's complement
-	v -= lb
-	return uw.writeValue(uint64(v), rawLength*8)
+	switch pc.Category() {
+	case Constrained:
+		err = uw.writeConstrainedWholeNumber(uint64(v)-uint64(pc.Lb), pc.Span())
+	case SemiConstrained:
+		err = uw.writeSemiConstraintWholeNumber(uint64(v)-uint64(pc.Lb), 0)
+	default:
+		err = uw.writeUnconstrainedWholeNumber(v)
+	}
+	return
 }
 
 // writeOctetsWithIndefiniteLength writes octets using indefinite length encoding
@@ -412,6 +474,10 @@ func (uw *UperWriter) WriteChoice(v uint64, uBound uint64, e bool) (err error) {
 
 	idx := v - 1 // Convert to 0-based index
 	isExtension := idx > uBound
+	if isExtension && !e {
+		err = ErrInextensible
+		return
+	}
 
 	// Write extension bit (if extensible)
 	if e {
@@ -420,38 +486,10 @@ func (uw *UperWriter) WriteChoice(v uint64, uBound uint64, e bool) (err error) {
 		}
 	}
 
-	// Handle extension alternative
+	// Extension alternative: index among the additions as a normally small
+	// non-negative whole number (X.691 clause 23.8)
 	if isExtension {
-		if !e {
-			err = fmt.Errorf("Choice extension not supported")
-			return
-		}
-
-		// Write large index flag
-		isLarge := idx > 63
-		if err = uw.WriteBool(isLarge); err != nil {
-			return
-		}
-
-		// Encode extension index
-		if !isLarge {
-			// Small extension index (≤63): encode in 6 bits
-			err = uw.writeValue(idx, 6)
-		} else {
-			// Large extension index (>63): encode as indefinite length octets
-			length := uint((bits.Len64(idx) + 7) >> 3)
-			if length == 0 {
-				length = 1
-			}
-			hexValue := make([]byte, length)
-			tempIdx := idx
-			for i := int(length) - 1; i >= 0; i-- {
-				hexValue[i] = byte(tempIdx & 0xFF)
-				tempIdx >>= 8
-			}
-			// err = uw.writeOctetsWithIndefiniteLength(hexValue)
-			err = uw.WriteOpenType(hexValue)
-		}
+		err = uw.writeNormallySmallNonNegativeValue(idx - uBound - 1)
 		return
 	}
 
@@ -460,6 +498,18 @@ func (uw *UperWriter) WriteChoice(v uint64, uBound uint64, e bool) (err error) {
 	return
 }
 
+// WriteChoiceValue writes a CHOICE alternative and its value: a root value
+// directly, an extension value wrapped in an open type.
+func (uw *UperWriter) WriteChoiceValue(v uint64, uBound uint64, e bool, value UperMarshaller) (err error) {
+	if err = uw.WriteChoice(v, uBound, e); err != nil {
+		return
+	}
+	if v-1 > uBound {
+		return uw.WriteOpenTypeValue(value)
+	}
+	return value.Encode(uw)
+}
+
 // WriteBoolean encodes an ASN.1 BOOLEAN value according to UPER rules.
 // A BOOLEAN is encoded as a single bit: 1 for true, 0 for false.
 func (uw *UperWriter) WriteBoolean(value bool) (err error) {
@@ -32,3 +32,9 @@ func (wErr *errorWrapper) Error() (errStr string) {
 	}
 	return
 }
+
+// Unwrap returns the wrapped error so that errors.Is and errors.As can see
+// through a chain of wrappers
+func (wErr *errorWrapper) Unwrap() error {
+	return wErr.prev
+}